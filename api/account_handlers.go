@@ -0,0 +1,778 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Account archives are zip files with a manifest.json at the root listing
+// every file in them. exportFormatVersion is bumped whenever the layout
+// changes in a way older importers cannot read.
+const (
+	exportFormat        = "image-to-palette-export"
+	exportFormatVersion = 1
+	exportManifestFile  = "manifest.json"
+	exportProfileFile   = "profile.json"
+)
+
+var errInvalidArchive = errors.New("file is not an account export archive")
+
+// Import either skips items whose content the account already has or
+// imports them anyway and flags them in the result.
+const (
+	importDuplicatesSkip = "skip"
+	importDuplicatesKeep = "keep"
+)
+
+const (
+	importStatusImported = "imported"
+	importStatusSkipped  = "skipped"
+	importStatusFailed   = "failed"
+)
+
+type ExportManifest struct {
+	Format     string                 `json:"format"`
+	Version    int                    `json:"version"`
+	ExportedAt time.Time              `json:"exportedAt"`
+	Profile    string                 `json:"profile"`
+	Palettes   []ExportPaletteEntry   `json:"palettes"`
+	Workspaces []ExportWorkspaceEntry `json:"workspaces"`
+}
+
+// ContentHash identifies the colors (palettes) or image and state
+// (workspaces) independently of names and tags. Import recomputes it from
+// the files to find items the account already has.
+type ExportPaletteEntry struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	File        string `json:"file"`
+	GPLFile     string `json:"gplFile"`
+	ContentHash string `json:"contentHash"`
+}
+
+type ExportWorkspaceEntry struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	File        string `json:"file"`
+	ImageFile   string `json:"imageFile"`
+	ImageType   string `json:"imageType"`
+	ContentHash string `json:"contentHash"`
+}
+
+type ExportProfile struct {
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ExportPalette struct {
+	Name      string    `json:"name"`
+	Palette   []Color   `json:"palette"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type ExportWorkspace struct {
+	Name      string             `json:"name"`
+	State     WorkspaceStateData `json:"state"`
+	Tags      []string           `json:"tags"`
+	CreatedAt time.Time          `json:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt"`
+}
+
+// ImportItemResult reports what happened to one manifest entry. ImportedAs
+// differs from Name when the name was taken; DuplicateOf is the ID of the
+// item with the same content.
+type ImportItemResult struct {
+	SourceID    string `json:"sourceId"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	ID          string `json:"id,omitempty"`
+	ImportedAs  string `json:"importedAs,omitempty"`
+	Duplicate   bool   `json:"duplicate"`
+	DuplicateOf string `json:"duplicateOf,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type ImportAccountResponse struct {
+	Palettes   []ImportItemResult `json:"palettes"`
+	Workspaces []ImportItemResult `json:"workspaces"`
+	Imported   int                `json:"imported"`
+	Skipped    int                `json:"skipped"`
+	Failed     int                `json:"failed"`
+}
+
+// maxImportItems caps the palettes plus workspaces one archive may list.
+const maxImportItems = 5000
+
+func accountImportMaxBytes() int64 {
+	return int64(getEnvInt("ACCOUNT_IMPORT_MAX_MB", 200)) << 20
+}
+
+// accountImportMaxInflatedBytes bounds everything read out of one archive,
+// which compression can make far larger than the upload itself.
+func accountImportMaxInflatedBytes() int64 {
+	return int64(getEnvInt("ACCOUNT_IMPORT_MAX_INFLATED_MB", 1024)) << 20
+}
+
+// Streaming starts once everything but the images has been loaded, so
+// database errors still get a proper status. A failure while writing can
+// only cut the archive short, which zip readers report as corrupt.
+func (s *Server) exportAccountHandler(c *gin.Context) {
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to export your data"})
+		return
+	}
+
+	export, err := s.loadAccountExport(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account"})
+		return
+	}
+
+	filename := fmt.Sprintf("image-to-palette-export-%s.zip", export.manifest.ExportedAt.Format("2006-01-02"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	if err := s.writeAccountExport(c.Request.Context(), c.Writer, export); err != nil {
+		log.Printf("Account export for user %d failed: %v", userID, err)
+	}
+}
+
+// accountExport is everything in the archive except the images, which are
+// read from the blob store while the archive is written.
+type accountExport struct {
+	manifest   ExportManifest
+	profile    ExportProfile
+	palettes   []ExportPalette
+	workspaces []exportedWorkspace
+}
+
+type exportedWorkspace struct {
+	row   Workspace
+	state WorkspaceStateData
+}
+
+func (s *Server) loadAccountExport(userID uint) (*accountExport, error) {
+	user, err := s.Users.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &accountExport{
+		manifest: ExportManifest{
+			Format:     exportFormat,
+			Version:    exportFormatVersion,
+			ExportedAt: time.Now().UTC(),
+			Profile:    exportProfileFile,
+			Palettes:   []ExportPaletteEntry{},
+			Workspaces: []ExportWorkspaceEntry{},
+		},
+		profile: ExportProfile{Name: user.Name, Email: user.Email, CreatedAt: user.CreatedAt},
+	}
+
+	palettes, err := listAll(func(page PageParams) ([]Palette, string, error) {
+		return s.Palettes.List(userID, ListFilter{}, page)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, palette := range palettes {
+		var colors []Color
+		if err := json.Unmarshal([]byte(palette.JsonData), &colors); err != nil {
+			return nil, fmt.Errorf("palette %d: failed to parse palette data", palette.ID)
+		}
+
+		base := "palettes/" + exportFileName(i, palette.Name)
+		export.palettes = append(export.palettes, ExportPalette{
+			Name:      palette.Name,
+			Palette:   colors,
+			Tags:      decodeTags(palette.Tags),
+			CreatedAt: palette.CreatedAt,
+			UpdatedAt: palette.UpdatedAt,
+		})
+		export.manifest.Palettes = append(export.manifest.Palettes, ExportPaletteEntry{
+			ID:          fmt.Sprintf("%d", palette.ID),
+			Name:        palette.Name,
+			File:        base + ".json",
+			GPLFile:     base + ".gpl",
+			ContentHash: paletteContentHash(colors),
+		})
+	}
+
+	workspaces, err := listAll(func(page PageParams) ([]Workspace, string, error) {
+		return s.Workspaces.List(userID, ListFilter{}, page)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, workspace := range workspaces {
+		imageHash, imageType, err := s.workspaceImageHash(userID, &workspace)
+		if err != nil {
+			return nil, err
+		}
+		state, err := parseWorkspaceState(workspace.JsonData)
+		if err != nil {
+			return nil, fmt.Errorf("workspace %d: %w", workspace.ID, err)
+		}
+
+		base := "workspaces/" + exportFileName(i, workspace.Name)
+		export.workspaces = append(export.workspaces, exportedWorkspace{row: workspace, state: state})
+		export.manifest.Workspaces = append(export.manifest.Workspaces, ExportWorkspaceEntry{
+			ID:          fmt.Sprintf("%d", workspace.ID),
+			Name:        workspace.Name,
+			File:        base + ".json",
+			ImageFile:   base + imageExtension(imageType),
+			ImageType:   imageType,
+			ContentHash: workspaceContentHash(imageHash, state),
+		})
+	}
+
+	return export, nil
+}
+
+func (s *Server) writeAccountExport(ctx context.Context, w io.Writer, export *accountExport) error {
+	zw := zip.NewWriter(w)
+
+	if err := writeZipJSON(zw, exportManifestFile, export.manifest); err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, exportProfileFile, export.profile); err != nil {
+		return err
+	}
+
+	for i, palette := range export.palettes {
+		entry := export.manifest.Palettes[i]
+		if err := writeZipJSON(zw, entry.File, palette); err != nil {
+			return err
+		}
+		if err := writeZipFile(zw, entry.GPLFile, renderPaletteGPL(palette.Name, withColorNames(palette.Palette))); err != nil {
+			return err
+		}
+	}
+
+	for i, exported := range export.workspaces {
+		entry := export.manifest.Workspaces[i]
+		workspace := exported.row
+		if err := writeZipJSON(zw, entry.File, ExportWorkspace{
+			Name:      workspace.Name,
+			State:     exported.state,
+			Tags:      decodeTags(workspace.Tags),
+			CreatedAt: workspace.CreatedAt,
+			UpdatedAt: workspace.UpdatedAt,
+		}); err != nil {
+			return err
+		}
+
+		image, _, err := loadWorkspaceImageBytes(ctx, s.Blobs, workspace)
+		if err != nil {
+			return fmt.Errorf("workspace %d: failed to load image: %w", workspace.ID, err)
+		}
+		if err := writeZipFile(zw, entry.ImageFile, image); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// workspaceImageHash returns the hash and type of the workspace image. Rows
+// that still carry their image inline are listed without it, so those are
+// reloaded in full first.
+func (s *Server) workspaceImageHash(userID uint, workspace *Workspace) (string, string, error) {
+	if workspace.ImageHash != "" {
+		return workspace.ImageHash, workspace.ImageType, nil
+	}
+
+	full, err := s.Workspaces.Get(userID, fmt.Sprintf("%d", workspace.ID))
+	if err != nil {
+		return "", "", err
+	}
+	*workspace = *full
+
+	data, contentType, err := decodeDataURL(workspace.ImageData)
+	if err != nil {
+		return "", "", fmt.Errorf("workspace %d: %w", workspace.ID, err)
+	}
+	return contentHash(data), contentType, nil
+}
+
+// listAll follows the cursor of a paginated store listing to the end.
+func listAll[T any](list func(PageParams) ([]T, string, error)) ([]T, error) {
+	var all []T
+	page := PageParams{Limit: maxPageLimit}
+	for {
+		rows, nextCursor, err := list(page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, rows...)
+		if nextCursor == "" {
+			return all, nil
+		}
+		if page.Cursor, err = decodeCursor(nextCursor); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func writeZipJSON(zw *zip.Writer, name string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeZipFile(zw, name, data)
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// exportFileName numbers entries so that items sharing a name still get
+// distinct files, and keeps the name readable in file managers.
+func exportFileName(index int, name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 40 {
+			break
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		slug = "untitled"
+	}
+	return fmt.Sprintf("%04d-%s", index+1, slug)
+}
+
+func imageExtension(contentType string) string {
+	switch contentType {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ".bin"
+	}
+}
+
+// paletteContentHash hashes the colors alone, upper-cased, so renaming a
+// palette or a color does not make it a different palette.
+func paletteContentHash(colors []Color) string {
+	hexes := make([]string, len(colors))
+	for i, color := range colors {
+		hexes[i] = strings.ToUpper(color.Hex)
+	}
+	return contentHash([]byte(strings.Join(hexes, ",")))
+}
+
+func workspaceContentHash(imageHash string, state WorkspaceStateData) string {
+	stateJSON, _ := json.Marshal(state)
+	return contentHash([]byte(imageHash + "\n" + string(stateJSON)))
+}
+
+// renderPaletteGPL writes the palette in the GIMP palette format that GIMP,
+// Inkscape and Krita import.
+func renderPaletteGPL(name string, colors []Color) []byte {
+	var b strings.Builder
+	b.WriteString("GIMP Palette\n")
+	fmt.Fprintf(&b, "Name: %s\n", gplText(name))
+	b.WriteString("Columns: 0\n#\n")
+
+	for _, color := range colors {
+		rgba, err := hexToRGBA(color.Hex)
+		if err != nil {
+			continue
+		}
+		label := color.Name
+		if label == "" {
+			label = fmt.Sprintf("#%02X%02X%02X", rgba.R, rgba.G, rgba.B)
+		}
+		fmt.Fprintf(&b, "%3d %3d %3d\t%s\n", rgba.R, rgba.G, rgba.B, gplText(label))
+	}
+
+	return []byte(b.String())
+}
+
+// GPL is line based, so names must stay on one line.
+func gplText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// importAccountHandler takes an archive made by exportAccountHandler as the
+// "file" form field. Items are imported one by one, so a bad entry fails on
+// its own and the rest of the archive still goes in.
+func (s *Server) importAccountHandler(c *gin.Context) {
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to import data"})
+		return
+	}
+
+	maxBytes := accountImportMaxBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Archive must be at most %d MB", maxBytes>>20)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+		return
+	}
+
+	duplicates := c.DefaultPostForm("duplicates", importDuplicatesSkip)
+	if duplicates != importDuplicatesSkip && duplicates != importDuplicatesKeep {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duplicates must be \"skip\" or \"keep\""})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded file: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	archive, err := openImportArchive(file, fileHeader.Size, maxBytes, accountImportMaxInflatedBytes())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := s.importAccount(c.Request.Context(), userID, archive, duplicates == importDuplicatesKeep)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// importArchive reads manifest entries straight from the zip. Nothing is
+// extracted to disk, so entry names are only ever used as lookup keys.
+// Each file may be read once, so a manifest cannot inflate the same entry
+// over and over.
+type importArchive struct {
+	manifest    ExportManifest
+	files       map[string]*zip.File
+	seen        map[string]bool
+	maxBytes    int64
+	maxInflated int64
+	inflated    int64
+}
+
+func openImportArchive(r io.ReaderAt, size, maxBytes, maxInflated int64) (*importArchive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errInvalidArchive
+	}
+
+	archive := &importArchive{
+		files:       make(map[string]*zip.File, len(zr.File)),
+		seen:        make(map[string]bool),
+		maxBytes:    maxBytes,
+		maxInflated: maxInflated,
+	}
+	for _, f := range zr.File {
+		archive.files[f.Name] = f
+	}
+
+	if err := archive.readJSON(exportManifestFile, &archive.manifest); err != nil || archive.manifest.Format != exportFormat {
+		return nil, errInvalidArchive
+	}
+	if archive.manifest.Version < 1 || archive.manifest.Version > exportFormatVersion {
+		return nil, fmt.Errorf("unsupported export version %d", archive.manifest.Version)
+	}
+	if len(archive.manifest.Palettes)+len(archive.manifest.Workspaces) > maxImportItems {
+		return nil, fmt.Errorf("archive must list at most %d palettes and workspaces", maxImportItems)
+	}
+
+	return archive, nil
+}
+
+// read enforces the size limits on the bytes actually inflated, since the
+// sizes in the zip headers are whatever the archive claims.
+func (a *importArchive) read(name string) ([]byte, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s is missing from the archive", name)
+	}
+	if a.seen[name] {
+		return nil, fmt.Errorf("%s is referenced more than once", name)
+	}
+	a.seen[name] = true
+
+	limit := min(a.maxBytes, a.maxInflated-a.inflated)
+	if limit <= 0 {
+		return nil, fmt.Errorf("archive expands to more than %d MB", a.maxInflated>>20)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s", name)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	a.inflated += int64(len(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s", name)
+	}
+	if int64(len(data)) > a.maxBytes {
+		return nil, fmt.Errorf("%s is too large", name)
+	}
+	if a.inflated > a.maxInflated {
+		return nil, fmt.Errorf("archive expands to more than %d MB", a.maxInflated>>20)
+	}
+	return data, nil
+}
+
+func (a *importArchive) readJSON(name string, value any) error {
+	data, err := a.read(name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("%s is not valid JSON", name)
+	}
+	return nil
+}
+
+// accountImport tracks the names and content hashes the account already
+// has, including items imported earlier in the same archive.
+type accountImport struct {
+	userID          uint
+	archive         *importArchive
+	keepDuplicates  bool
+	paletteNames    map[string]bool
+	paletteHashes   map[string]string
+	workspaceNames  map[string]bool
+	workspaceHashes map[string]string
+}
+
+func (s *Server) importAccount(ctx context.Context, userID uint, archive *importArchive, keepDuplicates bool) (*ImportAccountResponse, error) {
+	imp := &accountImport{
+		userID:          userID,
+		archive:         archive,
+		keepDuplicates:  keepDuplicates,
+		paletteNames:    make(map[string]bool),
+		paletteHashes:   make(map[string]string),
+		workspaceNames:  make(map[string]bool),
+		workspaceHashes: make(map[string]string),
+	}
+
+	palettes, err := listAll(func(page PageParams) ([]Palette, string, error) {
+		return s.Palettes.List(userID, ListFilter{}, page)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, palette := range palettes {
+		imp.paletteNames[strings.ToLower(palette.Name)] = true
+		var colors []Color
+		if json.Unmarshal([]byte(palette.JsonData), &colors) == nil {
+			imp.paletteHashes[paletteContentHash(colors)] = fmt.Sprintf("%d", palette.ID)
+		}
+	}
+
+	workspaces, err := listAll(func(page PageParams) ([]Workspace, string, error) {
+		return s.Workspaces.List(userID, ListFilter{}, page)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, workspace := range workspaces {
+		imp.workspaceNames[strings.ToLower(workspace.Name)] = true
+		imageHash, _, err := s.workspaceImageHash(userID, &workspace)
+		if err != nil {
+			continue
+		}
+		if state, err := parseWorkspaceState(workspace.JsonData); err == nil {
+			imp.workspaceHashes[workspaceContentHash(imageHash, state)] = fmt.Sprintf("%d", workspace.ID)
+		}
+	}
+
+	response := &ImportAccountResponse{
+		Palettes:   make([]ImportItemResult, 0, len(archive.manifest.Palettes)),
+		Workspaces: make([]ImportItemResult, 0, len(archive.manifest.Workspaces)),
+	}
+	for _, entry := range archive.manifest.Palettes {
+		response.Palettes = append(response.Palettes, response.count(s.importPalette(imp, entry)))
+	}
+	for _, entry := range archive.manifest.Workspaces {
+		response.Workspaces = append(response.Workspaces, response.count(s.importWorkspace(ctx, imp, entry)))
+	}
+
+	return response, nil
+}
+
+func (r *ImportAccountResponse) count(result ImportItemResult) ImportItemResult {
+	switch result.Status {
+	case importStatusImported:
+		r.Imported++
+	case importStatusSkipped:
+		r.Skipped++
+	default:
+		r.Failed++
+	}
+	return result
+}
+
+func (s *Server) importPalette(imp *accountImport, entry ExportPaletteEntry) ImportItemResult {
+	result := ImportItemResult{SourceID: entry.ID, Name: entry.Name, Status: importStatusFailed}
+
+	var palette ExportPalette
+	if err := imp.archive.readJSON(entry.File, &palette); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if len(palette.Palette) == 0 {
+		result.Error = "palette has no colors"
+		return result
+	}
+	for _, color := range palette.Palette {
+		if _, err := hexToRGBA(color.Hex); err != nil {
+			result.Error = fmt.Sprintf("invalid color %q", color.Hex)
+			return result
+		}
+	}
+	tags, err := normalizeTags(palette.Tags)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	hash := paletteContentHash(palette.Palette)
+	if !imp.checkDuplicate(&result, imp.paletteHashes, hash) {
+		return result
+	}
+
+	name := uniqueName(importName(palette.Name, entry.Name, "Imported palette"), imp.paletteNames)
+	created, err := s.saveUserPalette(imp.userID, name, palette.Palette, tags)
+	if err != nil {
+		result.Error = "failed to save palette"
+		return result
+	}
+
+	id := fmt.Sprintf("%d", created.ID)
+	imp.paletteNames[strings.ToLower(name)] = true
+	if _, ok := imp.paletteHashes[hash]; !ok {
+		imp.paletteHashes[hash] = id
+	}
+	result.Status, result.ID, result.ImportedAs = importStatusImported, id, name
+	return result
+}
+
+func (s *Server) importWorkspace(ctx context.Context, imp *accountImport, entry ExportWorkspaceEntry) ImportItemResult {
+	result := ImportItemResult{SourceID: entry.ID, Name: entry.Name, Status: importStatusFailed}
+
+	var workspace ExportWorkspace
+	if err := imp.archive.readJSON(entry.File, &workspace); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	image, err := imp.archive.read(entry.ImageFile)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	// The manifest's imageType is informational only; the bytes decide.
+	imageType, err := detectImageType(image)
+	if err != nil {
+		result.Error = fmt.Sprintf("%s is not a PNG, JPEG, GIF or WebP image", entry.ImageFile)
+		return result
+	}
+	tags, err := normalizeTags(workspace.Tags)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	hash := workspaceContentHash(contentHash(image), workspace.State)
+	if !imp.checkDuplicate(&result, imp.workspaceHashes, hash) {
+		return result
+	}
+
+	name := uniqueName(importName(workspace.Name, entry.Name, "Imported workspace"), imp.workspaceNames)
+	created, err := s.createUserWorkspace(ctx, imp.userID, name, workspace.State, tags, image, imageType)
+	if err != nil {
+		result.Error = "failed to save workspace"
+		return result
+	}
+
+	id := fmt.Sprintf("%d", created.ID)
+	imp.workspaceNames[strings.ToLower(name)] = true
+	if _, ok := imp.workspaceHashes[hash]; !ok {
+		imp.workspaceHashes[hash] = id
+	}
+	result.Status, result.ID, result.ImportedAs = importStatusImported, id, name
+	return result
+}
+
+// checkDuplicate flags the result when the content is already present and
+// reports whether the item should still be imported.
+func (imp *accountImport) checkDuplicate(result *ImportItemResult, hashes map[string]string, hash string) bool {
+	id, ok := hashes[hash]
+	if !ok {
+		return true
+	}
+	result.Duplicate, result.DuplicateOf = true, id
+	if imp.keepDuplicates {
+		return true
+	}
+	result.Status = importStatusSkipped
+	return false
+}
+
+func importName(name, manifestName, fallback string) string {
+	if name = strings.TrimSpace(name); name != "" {
+		return name
+	}
+	if manifestName = strings.TrimSpace(manifestName); manifestName != "" {
+		return manifestName
+	}
+	return fallback
+}
+
+// uniqueName appends " (2)", " (3)", ... until the name is free. Names are
+// compared case-insensitively.
+func uniqueName(name string, taken map[string]bool) string {
+	candidate := name
+	for n := 2; taken[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s (%d)", name, n)
+	}
+	return candidate
+}
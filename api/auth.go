@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -73,16 +74,15 @@ func generateJWTToken(user User) (string, error) {
 	return tokenString, nil
 }
 
-func createDemoUserIfNotExists() (*User, error) {
-	if DB == nil {
-		return nil, fmt.Errorf("database not available")
-	}
-
+func (s *Server) createDemoUserIfNotExists() (*User, error) {
 	demoEmail := "demo@imagepalette.com"
-	var demoUser User
 
-	if err := DB.Where("email = ?", demoEmail).First(&demoUser).Error; err == nil {
-		return &demoUser, nil
+	existing, err := s.Users.GetByEmail(demoEmail)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, errUserNotFound) {
+		return nil, err
 	}
 
 	hashedPassword, err := hashPassword("demopassword123")
@@ -90,24 +90,34 @@ func createDemoUserIfNotExists() (*User, error) {
 		return nil, fmt.Errorf("failed to hash demo password: %w", err)
 	}
 
-	demoUser = User{
+	demoUser := User{
 		Name:         "Demo User",
 		Email:        demoEmail,
 		PasswordHash: hashedPassword,
 	}
 
-	if err := DB.Create(&demoUser).Error; err != nil {
+	if err := s.Users.Create(&demoUser); err != nil {
 		return nil, fmt.Errorf("failed to create demo user: %w", err)
 	}
 
-	if err := createSamplePalettes(demoUser.ID); err != nil {
+	if err := s.createSamplePalettes(demoUser.ID); err != nil {
 		fmt.Printf("Warning: Failed to create sample palettes for demo user: %v\n", err)
 	}
 
 	return &demoUser, nil
 }
 
-func createSamplePalettes(userID uint) error {
+func (s *Server) initializeDemoUser() error {
+	_, err := s.createDemoUserIfNotExists()
+	if err != nil {
+		return fmt.Errorf("failed to initialize demo user: %w", err)
+	}
+
+	log.Println("Demo user initialized successfully")
+	return nil
+}
+
+func (s *Server) createSamplePalettes(userID uint) error {
 	samplePalettes := []struct {
 		Name     string
 		JsonData string
@@ -146,7 +156,7 @@ func createSamplePalettes(userID uint) error {
 			IsSystem: true,
 		}
 
-		if err := DB.Create(&dbPalette).Error; err != nil {
+		if err := s.Palettes.Create(&dbPalette); err != nil {
 			return fmt.Errorf("failed to create palette %s: %w", palette.Name, err)
 		}
 	}
@@ -219,17 +229,38 @@ func getCurrentUser(c *gin.Context) (uint, error) {
 	return uid, nil
 }
 
-func registerHandler(c *gin.Context) {
+// Admins are either flagged in the database or listed by email in
+// ADMIN_EMAILS (comma separated), which is handy for bootstrapping.
+func (s *Server) isAdminUser(userID uint) bool {
+	user, err := s.Users.Get(userID)
+	if err != nil {
+		return false
+	}
+	if user.IsAdmin {
+		return true
+	}
+
+	for _, email := range strings.Split(getEnv("ADMIN_EMAILS", ""), ",") {
+		if email = strings.TrimSpace(email); email != "" && strings.EqualFold(email, user.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) registerHandler(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var existingUser User
-	if err := DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+	if _, err := s.Users.GetByEmail(req.Email); err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "User with this email already exists"})
 		return
+	} else if !errors.Is(err, errUserNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
 	}
 
 	hashedPassword, err := hashPassword(req.Password)
@@ -244,7 +275,7 @@ func registerHandler(c *gin.Context) {
 		PasswordHash: hashedPassword,
 	}
 
-	if err := DB.Create(&user).Error; err != nil {
+	if err := s.Users.Create(&user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
@@ -263,15 +294,15 @@ func registerHandler(c *gin.Context) {
 	})
 }
 
-func loginHandler(c *gin.Context) {
+func (s *Server) loginHandler(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var user User
-	if err := DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+	user, err := s.Users.GetByEmail(req.Email)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
@@ -281,7 +312,7 @@ func loginHandler(c *gin.Context) {
 		return
 	}
 
-	token, err := generateJWTToken(user)
+	token, err := generateJWTToken(*user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -290,20 +321,20 @@ func loginHandler(c *gin.Context) {
 	user.PasswordHash = ""
 	c.JSON(http.StatusOK, AuthResponse{
 		Token:   token,
-		User:    user,
+		User:    *user,
 		Message: "Login successful",
 	})
 }
 
-func getMeHandler(c *gin.Context) {
+func (s *Server) getMeHandler(c *gin.Context) {
 	userID, err := getCurrentUser(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	var user User
-	if err := DB.First(&user, userID).Error; err != nil {
+	user, err := s.Users.Get(userID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -312,7 +343,7 @@ func getMeHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"user": user})
 }
 
-func changePasswordHandler(c *gin.Context) {
+func (s *Server) changePasswordHandler(c *gin.Context) {
 	userID, err := getCurrentUser(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
@@ -330,8 +361,8 @@ func changePasswordHandler(c *gin.Context) {
 		return
 	}
 
-	var user User
-	if err := DB.First(&user, userID).Error; err != nil {
+	user, err := s.Users.Get(userID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -347,7 +378,7 @@ func changePasswordHandler(c *gin.Context) {
 		return
 	}
 
-	if err := DB.Model(&user).Update("password_hash", hashedPassword).Error; err != nil {
+	if err := s.Users.UpdatePasswordHash(user.ID, hashedPassword); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
 		return
 	}
@@ -355,8 +386,8 @@ func changePasswordHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
 }
 
-func demoLoginHandler(c *gin.Context) {
-	demoUser, err := createDemoUserIfNotExists()
+func (s *Server) demoLoginHandler(c *gin.Context) {
+	demoUser, err := s.createDemoUserIfNotExists()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create demo user"})
 		return
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	errBlobNotFound = errors.New("blob not found")
+	errInvalidImage = errors.New("image must be a PNG, JPEG, GIF or WebP base64 data URL")
+)
+
+// allowedImageTypes are the formats accepted for workspace images. They are
+// all raster formats a browser renders inertly; SVG and HTML are not.
+var allowedImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// BlobStore keeps binary objects such as workspace images outside the
+// database. Keys are content hashes, so writing the same bytes twice is
+// harmless and identical images are stored once.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var Blobs BlobStore
+
+// BLOB_STORE selects the backend: "local" (default) writes under BLOB_DIR,
+// "s3" talks to any S3-compatible service such as MinIO.
+func InitBlobStore() error {
+	switch backend := getEnv("BLOB_STORE", "local"); backend {
+	case "local":
+		store, err := newLocalBlobStore(getEnv("BLOB_DIR", "./data/blobs"))
+		if err != nil {
+			return err
+		}
+		Blobs = store
+	case "s3":
+		store, err := newS3BlobStore(S3Config{
+			Endpoint:  getEnv("S3_ENDPOINT", "http://localhost:9000"),
+			Region:    getEnv("S3_REGION", "us-east-1"),
+			Bucket:    getEnv("S3_BUCKET", "image-to-palette"),
+			AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			SecretKey: getEnv("S3_SECRET_KEY", ""),
+		})
+		if err != nil {
+			return err
+		}
+		Blobs = store
+	default:
+		return fmt.Errorf("unknown BLOB_STORE %q", backend)
+	}
+
+	log.Printf("Blob store initialized (%s)", getEnv("BLOB_STORE", "local"))
+	return nil
+}
+
+func contentHash(data []byte) string {
+	return sha256Hex(data)
+}
+
+// Image blobs live under images/ so other kinds of objects can share a bucket.
+func imageBlobKey(hash string) string {
+	return "images/" + hash
+}
+
+// decodeDataURL splits a "data:<type>;base64,<payload>" URL, which is how the
+// frontend sends images, into its content type and bytes. The type is taken
+// from the bytes, and a declared type that disagrees is rejected.
+func decodeDataURL(dataURL string) ([]byte, string, error) {
+	header, payload, ok := strings.Cut(dataURL, ",")
+	if !ok || !strings.HasPrefix(header, "data:") || !strings.HasSuffix(header, ";base64") {
+		return nil, "", errInvalidImage
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(data) == 0 {
+		return nil, "", errInvalidImage
+	}
+
+	contentType, err := detectImageType(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if declared := strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64"); declared != "" && declared != contentType {
+		return nil, "", errInvalidImage
+	}
+
+	return data, contentType, nil
+}
+
+// detectImageType sniffs the format from the bytes themselves and rejects
+// anything outside allowedImageTypes.
+func detectImageType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !allowedImageTypes[contentType] {
+		return "", errInvalidImage
+	}
+	return contentType, nil
+}
+
+func encodeDataURL(data []byte, contentType string) string {
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+type localBlobStore struct {
+	root string
+}
+
+func newLocalBlobStore(root string) (*localBlobStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &localBlobStore{root: root}, nil
+}
+
+// Objects are fanned out by the first two hash characters to keep directory
+// sizes reasonable.
+func (s *localBlobStore) path(key string) string {
+	dir, name := filepath.Split(filepath.FromSlash(key))
+	if len(name) > 2 {
+		dir = filepath.Join(dir, name[:2])
+	}
+	return filepath.Join(s.root, dir, name)
+}
+
+func (s *localBlobStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial object.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *localBlobStore) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errBlobNotFound
+	}
+	return data, err
+}
+
+func (s *localBlobStore) Exists(_ context.Context, key string) (bool, error) {
+	_, err := os.Stat(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *localBlobStore) Delete(_ context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errCollectionNotFound = errors.New("collection not found or unauthorized")
+	errCollectionExists   = errors.New("a collection with this name already exists")
+)
+
+type CollectionData struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	PaletteCount   int       `json:"paletteCount"`
+	WorkspaceCount int       `json:"workspaceCount"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type CollectionRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+type GetCollectionsResponse struct {
+	Collections []CollectionData `json:"collections"`
+}
+
+// collectionMember describes one kind of collection member: how to look up
+// an item the user owns.
+type collectionMember struct {
+	load func(s *Server, userID uint, id string) (any, error)
+}
+
+var (
+	collectionPalettes = collectionMember{
+		load: func(s *Server, userID uint, id string) (any, error) {
+			return s.Palettes.Get(userID, id)
+		},
+	}
+	collectionWorkspaces = collectionMember{
+		load: func(s *Server, userID uint, id string) (any, error) {
+			return s.Workspaces.GetSummary(userID, id)
+		},
+	}
+)
+
+func (s *Server) getCollectionsHandler(c *gin.Context) {
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to get collections"})
+		return
+	}
+
+	collections, err := s.getUserCollections(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collections"})
+		return
+	}
+
+	c.JSON(http.StatusOK, GetCollectionsResponse{Collections: collections})
+}
+
+func (s *Server) getCollectionHandler(c *gin.Context) {
+	collectionID := c.Param("id")
+	if collectionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Collection ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to get collections"})
+		return
+	}
+
+	collection, err := s.Collections.GetSummary(userID, collectionID)
+	if err != nil {
+		if errors.Is(err, errCollectionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collection"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toCollectionData(*collection))
+}
+
+func (s *Server) createCollectionHandler(c *gin.Context) {
+	var req CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Collection name is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to create collections"})
+		return
+	}
+
+	collection, err := s.createUserCollection(userID, name)
+	if err != nil {
+		if errors.Is(err, errCollectionExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create collection"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+func (s *Server) renameCollectionHandler(c *gin.Context) {
+	collectionID := c.Param("id")
+	if collectionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Collection ID is required"})
+		return
+	}
+
+	var req CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Collection name is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to rename collections"})
+		return
+	}
+
+	if err := s.renameUserCollection(userID, collectionID, name); err != nil {
+		switch {
+		case errors.Is(err, errCollectionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errCollectionExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename collection"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection renamed successfully", "name": name})
+}
+
+func (s *Server) deleteCollectionHandler(c *gin.Context) {
+	collectionID := c.Param("id")
+	if collectionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Collection ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to delete collections"})
+		return
+	}
+
+	if err := s.deleteUserCollection(userID, collectionID); err != nil {
+		if errors.Is(err, errCollectionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete collection"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted successfully"})
+}
+
+func (s *Server) addCollectionPaletteHandler(c *gin.Context) {
+	s.updateCollectionMembership(c, collectionPalettes, c.Param("paletteId"), true)
+}
+
+func (s *Server) removeCollectionPaletteHandler(c *gin.Context) {
+	s.updateCollectionMembership(c, collectionPalettes, c.Param("paletteId"), false)
+}
+
+func (s *Server) addCollectionWorkspaceHandler(c *gin.Context) {
+	s.updateCollectionMembership(c, collectionWorkspaces, c.Param("workspaceId"), true)
+}
+
+func (s *Server) removeCollectionWorkspaceHandler(c *gin.Context) {
+	s.updateCollectionMembership(c, collectionWorkspaces, c.Param("workspaceId"), false)
+}
+
+func (s *Server) updateCollectionMembership(c *gin.Context, member collectionMember, memberID string, add bool) {
+	collectionID := c.Param("id")
+	if collectionID == "" || memberID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Collection ID and item ID are required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to update collections"})
+		return
+	}
+
+	if err := s.setCollectionMembership(userID, collectionID, member, memberID, add); err != nil {
+		switch {
+		case errors.Is(err, errCollectionNotFound), errors.Is(err, errPaletteNotFound), errors.Is(err, errWorkspaceNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update collection"})
+		}
+		return
+	}
+
+	if add {
+		c.JSON(http.StatusOK, gin.H{"message": "Added to collection"})
+	} else {
+		c.JSON(http.StatusOK, gin.H{"message": "Removed from collection"})
+	}
+}
+
+func (s *Server) getUserCollections(userID uint) ([]CollectionData, error) {
+	summaries, err := s.Collections.List(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	collections := make([]CollectionData, 0, len(summaries))
+	for _, summary := range summaries {
+		collections = append(collections, toCollectionData(summary))
+	}
+	return collections, nil
+}
+
+func toCollectionData(summary CollectionSummary) CollectionData {
+	return CollectionData{
+		ID:             fmt.Sprintf("%d", summary.ID),
+		Name:           summary.Name,
+		PaletteCount:   summary.PaletteCount,
+		WorkspaceCount: summary.WorkspaceCount,
+		CreatedAt:      summary.CreatedAt,
+		UpdatedAt:      summary.UpdatedAt,
+	}
+}
+
+func (s *Server) createUserCollection(userID uint, name string) (*CollectionData, error) {
+	collection := Collection{UserID: userID, Name: name}
+	if err := s.Collections.Create(&collection); err != nil {
+		return nil, err
+	}
+
+	data := toCollectionData(CollectionSummary{
+		ID:        collection.ID,
+		Name:      collection.Name,
+		CreatedAt: collection.CreatedAt,
+		UpdatedAt: collection.UpdatedAt,
+	})
+	return &data, nil
+}
+
+func (s *Server) renameUserCollection(userID uint, collectionID, name string) error {
+	collection, err := s.Collections.Get(userID, collectionID)
+	if err != nil {
+		return err
+	}
+	return s.Collections.Rename(collection, name)
+}
+
+// Only the links are removed; the palettes and workspaces themselves stay.
+func (s *Server) deleteUserCollection(userID uint, collectionID string) error {
+	collection, err := s.Collections.Get(userID, collectionID)
+	if err != nil {
+		return err
+	}
+	return s.Collections.Delete(collection)
+}
+
+// Adding an item that is already a member, or removing one that is not, is
+// a no-op rather than an error.
+func (s *Server) setCollectionMembership(userID uint, collectionID string, member collectionMember, memberID string, add bool) error {
+	collection, err := s.Collections.Get(userID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	item, err := member.load(s, userID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if add {
+		return s.Collections.AddMember(collection, item)
+	}
+	return s.Collections.RemoveMember(collection, item)
+}
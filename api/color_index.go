@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// PaletteColor and WorkspaceColor keep the CIELAB value of every color, so
+// Delta E filters run in SQL against an index instead of decoding json_data
+// row by row. They are rewritten whenever the colors change.
+type PaletteColor struct {
+	PaletteID uint `gorm:"primaryKey;autoIncrement:false"`
+	Position  int  `gorm:"primaryKey;autoIncrement:false"`
+	L         float64
+	A         float64
+	B         float64
+}
+
+type WorkspaceColor struct {
+	WorkspaceID uint `gorm:"primaryKey;autoIncrement:false"`
+	Position    int  `gorm:"primaryKey;autoIncrement:false"`
+	L           float64
+	A           float64
+	B           float64
+}
+
+// maxLightnessWeight is the largest S_L in CIEDE2000, reached at L = 0 or
+// 100. Since the lightness term alone can never exceed the full distance,
+// colors within Delta E d of a target differ in L by at most d times this,
+// which lets the index on l narrow the candidates before ciede2000 runs.
+var maxLightnessWeight = 1 + 0.015*2500/math.Sqrt(20+2500)
+
+// indexPaletteColors replaces the indexed colors of a palette. It takes the
+// raw json_data, so callers that only have the stored row need not parse it.
+func indexPaletteColors(tx *gorm.DB, paletteID uint, jsonData string) error {
+	if err := tx.Where("palette_id = ?", paletteID).Delete(&PaletteColor{}).Error; err != nil {
+		return err
+	}
+
+	var colors []Color
+	if err := json.Unmarshal([]byte(jsonData), &colors); err != nil {
+		return nil
+	}
+
+	var rows []PaletteColor
+	for position, c := range colors {
+		if rgba, err := hexToRGBA(c.Hex); err == nil {
+			lab := rgbaToLab(rgba)
+			rows = append(rows, PaletteColor{PaletteID: paletteID, Position: position, L: lab.L, A: lab.A, B: lab.B})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+func indexWorkspaceColors(tx *gorm.DB, workspaceID uint, colors []Color) error {
+	if err := tx.Where("workspace_id = ?", workspaceID).Delete(&WorkspaceColor{}).Error; err != nil {
+		return err
+	}
+
+	var rows []WorkspaceColor
+	for position, c := range colors {
+		if rgba, err := hexToRGBA(c.Hex); err == nil {
+			lab := rgbaToLab(rgba)
+			rows = append(rows, WorkspaceColor{WorkspaceID: workspaceID, Position: position, L: lab.L, A: lab.A, B: lab.B})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+// indexMissingColors fills the color index for rows written before it
+// existed. Like migrateWorkspaceImages it runs at startup in batches; rows
+// without colors are looked at again each time, which is cheap.
+func indexMissingColors() error {
+	if DB == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	indexed := 0
+
+	var palettes []Palette
+	result := DB.Unscoped().Select("id", "json_data").
+		Where("NOT EXISTS (SELECT 1 FROM palette_colors WHERE palette_colors.palette_id = palettes.id)").
+		FindInBatches(&palettes, 100, func(tx *gorm.DB, _ int) error {
+			for _, palette := range palettes {
+				if err := indexPaletteColors(DB, palette.ID, palette.JsonData); err != nil {
+					return err
+				}
+				indexed++
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	var workspaces []Workspace
+	result = DB.Unscoped().Select("id", "json_data").
+		Where("NOT EXISTS (SELECT 1 FROM workspace_colors WHERE workspace_colors.workspace_id = workspaces.id)").
+		FindInBatches(&workspaces, 100, func(tx *gorm.DB, _ int) error {
+			for _, workspace := range workspaces {
+				state, err := parseWorkspaceState(workspace.JsonData)
+				if err != nil || len(state.Colors) == 0 {
+					continue
+				}
+				if err := indexWorkspaceColors(DB, workspace.ID, state.Colors); err != nil {
+					return err
+				}
+				indexed++
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if indexed > 0 {
+		log.Printf("Indexed the colors of %d palettes and workspaces", indexed)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"sync"
+
+	"golang.org/x/image/colornames"
+)
+
+type namedColor struct {
+	Name string
+	Hex  string
+	Lab  cielab
+}
+
+var (
+	namedColors     []namedColor
+	namedColorsOnce sync.Once
+)
+
+// loadNamedColors returns the CSS named colors with their Lab values, in
+// name order so ties resolve the same way on every run.
+func loadNamedColors() []namedColor {
+	namedColorsOnce.Do(func() {
+		cssNames := make([]string, 0, len(colornames.Map))
+		for name := range colornames.Map {
+			cssNames = append(cssNames, name)
+		}
+		sort.Strings(cssNames)
+
+		for _, name := range cssNames {
+			rgba := colornames.Map[name]
+			namedColors = append(namedColors, namedColor{
+				Name: name,
+				Hex:  rgbaToHex(rgba),
+				Lab:  rgbaToLab(rgba),
+			})
+		}
+	})
+
+	return namedColors
+}
+
+func nearestColorName(c color.RGBA) (string, float64) {
+	lab := rgbaToLab(c)
+
+	best := ""
+	bestDist := math.MaxFloat64
+	for _, named := range loadNamedColors() {
+		if d := deltaE2000(lab, named.Lab); d < bestDist {
+			best = named.Name
+			bestDist = d
+		}
+	}
+
+	return best, bestDist
+}
+
+func withColorNames(palette []Color) []Color {
+	named := make([]Color, len(palette))
+	for i, p := range palette {
+		named[i] = p
+		if p.Name != "" {
+			continue
+		}
+		if rgba, err := hexToRGBA(p.Hex); err == nil {
+			named[i].Name, _ = nearestColorName(rgba)
+		}
+	}
+	return named
+}
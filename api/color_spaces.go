@@ -0,0 +1,304 @@
+package main
+
+import (
+	"image/color"
+	"math"
+)
+
+type oklab struct {
+	L float64
+	A float64
+	B float64
+}
+
+type oklch struct {
+	L float64
+	C float64
+	H float64
+}
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func rgbaToLinear(c color.RGBA) (float64, float64, float64) {
+	return srgbToLinear(float64(c.R) / 255),
+		srgbToLinear(float64(c.G) / 255),
+		srgbToLinear(float64(c.B) / 255)
+}
+
+func linearToRGBA(r, g, b float64) color.RGBA {
+	to8 := func(v float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(1, linearToSrgb(v))) * 255))
+	}
+	return color.RGBA{R: to8(r), G: to8(g), B: to8(b), A: 255}
+}
+
+func linearToOklab(r, g, b float64) oklab {
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	return oklab{
+		L: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		A: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		B: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+func (c oklab) toLinear() (float64, float64, float64) {
+	l := c.L + 0.3963377774*c.A + 0.2158037573*c.B
+	m := c.L - 0.1055613458*c.A - 0.0638541728*c.B
+	s := c.L - 0.0894841775*c.A - 1.2914855480*c.B
+
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	return 4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		-1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		-0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+}
+
+func (c oklab) toOklch() oklch {
+	h := math.Atan2(c.B, c.A) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return oklch{L: c.L, C: math.Hypot(c.A, c.B), H: h}
+}
+
+func (c oklch) toOklab() oklab {
+	rad := c.H * math.Pi / 180
+	return oklab{L: c.L, A: c.C * math.Cos(rad), B: c.C * math.Sin(rad)}
+}
+
+func rgbaToOklab(c color.RGBA) oklab {
+	return linearToOklab(rgbaToLinear(c))
+}
+
+func rgbaToOklch(c color.RGBA) oklch {
+	return rgbaToOklab(c).toOklch()
+}
+
+func inLinearGamut(r, g, b float64) bool {
+	const eps = 1e-6
+	return r >= -eps && r <= 1+eps && g >= -eps && g <= 1+eps && b >= -eps && b <= 1+eps
+}
+
+// Maps an OKLCH color into sRGB by reducing chroma at constant lightness and
+// hue until the color fits, which keeps ramps from shifting hue at the extremes.
+func oklchToRGBA(c oklch) color.RGBA {
+	c.L = math.Max(0, math.Min(1, c.L))
+	if c.L >= 1 {
+		return color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+	if c.L <= 0 {
+		return color.RGBA{A: 255}
+	}
+
+	if r, g, b := c.toOklab().toLinear(); inLinearGamut(r, g, b) {
+		return linearToRGBA(r, g, b)
+	}
+
+	lo, hi := 0.0, c.C
+	for hi-lo > 1e-4 {
+		mid := (lo + hi) / 2
+		probe := oklch{L: c.L, C: mid, H: c.H}
+		if r, g, b := probe.toOklab().toLinear(); inLinearGamut(r, g, b) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return linearToRGBA(oklch{L: c.L, C: lo, H: c.H}.toOklab().toLinear())
+}
+
+func rgbaToHex(c color.RGBA) string {
+	return createColor(c.R, c.G, c.B).Hex
+}
+
+type hsl struct {
+	H float64
+	S float64
+	L float64
+}
+
+func rgbaToHSL(c color.RGBA) hsl {
+	r := float64(c.R) / 255
+	g := float64(c.G) / 255
+	b := float64(c.B) / 255
+
+	maxC := math.Max(r, math.Max(g, b))
+	minC := math.Min(r, math.Min(g, b))
+	l := (maxC + minC) / 2
+
+	if maxC == minC {
+		return hsl{H: 0, S: 0, L: l}
+	}
+
+	d := maxC - minC
+	s := d / (1 - math.Abs(2*l-1))
+
+	var h float64
+	switch maxC {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+
+	return hsl{H: h, S: s, L: l}
+}
+
+func hslToRGBA(c hsl) color.RGBA {
+	h := math.Mod(c.H, 360)
+	if h < 0 {
+		h += 360
+	}
+
+	chroma := (1 - math.Abs(2*c.L-1)) * c.S
+	x := chroma * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := c.L - chroma/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = chroma, x, 0
+	case h < 120:
+		r, g, b = x, chroma, 0
+	case h < 180:
+		r, g, b = 0, chroma, x
+	case h < 240:
+		r, g, b = 0, x, chroma
+	case h < 300:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+
+	to8 := func(v float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(1, v+m)) * 255))
+	}
+	return color.RGBA{R: to8(r), G: to8(g), B: to8(b), A: 255}
+}
+
+type cielab struct {
+	L float64
+	A float64
+	B float64
+}
+
+func rgbaToLab(c color.RGBA) cielab {
+	r, g, b := rgbaToLinear(c)
+
+	// sRGB to XYZ, normalized to the D65 white point.
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 216.0/24389.0 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27.0*t + 16) / 116
+	}
+
+	fx, fy, fz := f(x), f(y), f(z)
+	return cielab{L: 116*fy - 16, A: 500 * (fx - fy), B: 200 * (fy - fz)}
+}
+
+func deltaE2000(c1, c2 cielab) float64 {
+	const kL, kC, kH = 1.0, 1.0, 1.0
+
+	deg := math.Pi / 180
+
+	c1ab := math.Hypot(c1.A, c1.B)
+	c2ab := math.Hypot(c2.A, c2.B)
+	cBar := (c1ab + c2ab) / 2
+	cBar7 := math.Pow(cBar, 7)
+	g := 0.5 * (1 - math.Sqrt(cBar7/(cBar7+math.Pow(25, 7))))
+
+	a1 := (1 + g) * c1.A
+	a2 := (1 + g) * c2.A
+	cp1 := math.Hypot(a1, c1.B)
+	cp2 := math.Hypot(a2, c2.B)
+
+	hp := func(b, a float64) float64 {
+		if a == 0 && b == 0 {
+			return 0
+		}
+		h := math.Atan2(b, a) / deg
+		if h < 0 {
+			h += 360
+		}
+		return h
+	}
+	hp1 := hp(c1.B, a1)
+	hp2 := hp(c2.B, a2)
+
+	dL := c2.L - c1.L
+	dC := cp2 - cp1
+
+	var dh float64
+	if cp1*cp2 != 0 {
+		dh = hp2 - hp1
+		if dh > 180 {
+			dh -= 360
+		} else if dh < -180 {
+			dh += 360
+		}
+	}
+	dH := 2 * math.Sqrt(cp1*cp2) * math.Sin(dh*deg/2)
+
+	lBar := (c1.L + c2.L) / 2
+	cpBar := (cp1 + cp2) / 2
+
+	hBar := hp1 + hp2
+	if cp1*cp2 != 0 {
+		if math.Abs(hp1-hp2) > 180 {
+			if hBar < 360 {
+				hBar += 360
+			} else {
+				hBar -= 360
+			}
+		}
+		hBar /= 2
+	}
+
+	t := 1 - 0.17*math.Cos((hBar-30)*deg) +
+		0.24*math.Cos(2*hBar*deg) +
+		0.32*math.Cos((3*hBar+6)*deg) -
+		0.20*math.Cos((4*hBar-63)*deg)
+
+	lBar50 := (lBar - 50) * (lBar - 50)
+	sL := 1 + 0.015*lBar50/math.Sqrt(20+lBar50)
+	sC := 1 + 0.045*cpBar
+	sH := 1 + 0.015*cpBar*t
+
+	cpBar7 := math.Pow(cpBar, 7)
+	rC := 2 * math.Sqrt(cpBar7/(cpBar7+math.Pow(25, 7)))
+	dTheta := 30 * math.Exp(-math.Pow((hBar-275)/25, 2))
+	rT := -math.Sin(2*dTheta*deg) * rC
+
+	lTerm := dL / (kL * sL)
+	cTerm := dC / (kC * sC)
+	hTerm := dH / (kH * sH)
+
+	return math.Sqrt(lTerm*lTerm + cTerm*cTerm + hTerm*hTerm + rT*cTerm*hTerm)
+}
@@ -49,6 +49,18 @@ func hexToRGBA(s string) (color.RGBA, error) {
 	}, nil
 }
 
+func parsePaletteRGBAs(palette []Color) ([]color.RGBA, error) {
+	rgbas := make([]color.RGBA, len(palette))
+	for i, p := range palette {
+		rgba, err := hexToRGBA(p.Hex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color %q at index %d", p.Hex, i)
+		}
+		rgbas[i] = rgba
+	}
+	return rgbas, nil
+}
+
 func toRGBA(c color.Color) color.RGBA {
 	r, g, b, a := c.RGBA()
 	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pairs at or beyond this Delta E count as no match at all when scoring.
+const compareMaxDeltaE = 50.0
+
+type PaletteInput struct {
+	Palette   []Color `json:"palette"`
+	PaletteID string  `json:"paletteId"`
+}
+
+type PaletteCompareRequest struct {
+	First  PaletteInput `json:"first"`
+	Second PaletteInput `json:"second"`
+}
+
+type PalettePairing struct {
+	First       int     `json:"first"`
+	Second      int     `json:"second"`
+	FirstColor  Color   `json:"firstColor"`
+	SecondColor Color   `json:"secondColor"`
+	DeltaE      float64 `json:"deltaE"`
+}
+
+type PaletteCompareResponse struct {
+	Pairings        []PalettePairing `json:"pairings"`
+	UnmatchedFirst  []int            `json:"unmatchedFirst"`
+	UnmatchedSecond []int            `json:"unmatchedSecond"`
+	AverageDeltaE   float64          `json:"averageDeltaE"`
+	Similarity      float64          `json:"similarity"`
+}
+
+func (s *Server) paletteCompareHandler(c *gin.Context) {
+	var req PaletteCompareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	first, ok := s.resolvePaletteColors(c, req.First.Palette, req.First.PaletteID)
+	if !ok {
+		return
+	}
+	second, ok := s.resolvePaletteColors(c, req.Second.Palette, req.Second.PaletteID)
+	if !ok {
+		return
+	}
+
+	resp, err := comparePalettes(first, second)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func comparePalettes(first, second []Color) (*PaletteCompareResponse, error) {
+	firstRGBAs, err := parsePaletteRGBAs(first)
+	if err != nil {
+		return nil, err
+	}
+	secondRGBAs, err := parsePaletteRGBAs(second)
+	if err != nil {
+		return nil, err
+	}
+
+	costs := make([][]float64, len(firstRGBAs))
+	for i, a := range firstRGBAs {
+		labA := rgbaToLab(a)
+		costs[i] = make([]float64, len(secondRGBAs))
+		for j, b := range secondRGBAs {
+			costs[i][j] = deltaE2000(labA, rgbaToLab(b))
+		}
+	}
+
+	resp := &PaletteCompareResponse{
+		Pairings:        []PalettePairing{},
+		UnmatchedFirst:  []int{},
+		UnmatchedSecond: []int{},
+	}
+
+	assignment := solveAssignment(costs)
+	matchedSecond := make([]bool, len(second))
+
+	var totalDeltaE, score float64
+	for i, j := range assignment {
+		if j < 0 {
+			resp.UnmatchedFirst = append(resp.UnmatchedFirst, i)
+			continue
+		}
+		matchedSecond[j] = true
+
+		d := costs[i][j]
+		totalDeltaE += d
+		score += math.Max(0, 1-d/compareMaxDeltaE)
+
+		resp.Pairings = append(resp.Pairings, PalettePairing{
+			First:       i,
+			Second:      j,
+			FirstColor:  first[i],
+			SecondColor: second[j],
+			DeltaE:      math.Round(d*100) / 100,
+		})
+	}
+	for j, matched := range matchedSecond {
+		if !matched {
+			resp.UnmatchedSecond = append(resp.UnmatchedSecond, j)
+		}
+	}
+
+	if len(resp.Pairings) > 0 {
+		resp.AverageDeltaE = math.Round(totalDeltaE/float64(len(resp.Pairings))*100) / 100
+	}
+
+	// Unmatched colors score zero, so palettes of different sizes can never
+	// be fully similar.
+	if size := max(len(first), len(second)); size > 0 {
+		resp.Similarity = math.Round(score/float64(size)*1000) / 1000
+	}
+
+	return resp, nil
+}
+
+// Hungarian algorithm (Kuhn-Munkres with potentials) for a rectangular cost
+// matrix. Returns, for every row, the column assigned to it or -1 when there
+// are more rows than columns. It is cubic in the palette size, which the
+// handler keeps within maxPaletteColors.
+func solveAssignment(costs [][]float64) []int {
+	rows := len(costs)
+	if rows == 0 {
+		return []int{}
+	}
+	cols := len(costs[0])
+
+	transposed := rows > cols
+	if transposed {
+		t := make([][]float64, cols)
+		for j := range t {
+			t[j] = make([]float64, rows)
+			for i := range rows {
+				t[j][i] = costs[i][j]
+			}
+		}
+		costs = t
+		rows, cols = cols, rows
+	}
+
+	u := make([]float64, rows+1)
+	v := make([]float64, cols+1)
+	p := make([]int, cols+1)
+	way := make([]int, cols+1)
+
+	for i := 1; i <= rows; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, cols+1)
+		used := make([]bool, cols+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+			for j := 1; j <= cols; j++ {
+				if used[j] {
+					continue
+				}
+				cur := costs[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= cols; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	if transposed {
+		result := make([]int, cols)
+		for j := range result {
+			result[j] = -1
+		}
+		for j := 1; j <= cols; j++ {
+			if p[j] != 0 {
+				result[j-1] = p[j] - 1
+			}
+		}
+		return result
+	}
+
+	result := make([]int, rows)
+	for i := range result {
+		result[i] = -1
+	}
+	for j := 1; j <= cols; j++ {
+		if p[j] != 0 {
+			result[p[j]-1] = j - 1
+		}
+	}
+	return result
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	wcagAA      = 4.5
+	wcagAALarge = 3.0
+	wcagAAA     = 7.0
+)
+
+type PaletteContrastRequest struct {
+	Palette     []Color `json:"palette"`
+	PaletteID   string  `json:"paletteId"`
+	MinContrast float64 `json:"minContrast"`
+}
+
+type ContrastPair struct {
+	Foreground string  `json:"foreground"`
+	Background string  `json:"background"`
+	Ratio      float64 `json:"ratio"`
+	APCA       float64 `json:"apca"`
+	AA         bool    `json:"aa"`
+	AALarge    bool    `json:"aaLarge"`
+	AAA        bool    `json:"aaa"`
+	AAALarge   bool    `json:"aaaLarge"`
+	Suggestion string  `json:"suggestion,omitempty"`
+}
+
+type PaletteContrastResponse struct {
+	Palette     []Color          `json:"palette"`
+	MinContrast float64          `json:"minContrast"`
+	Matrix      [][]ContrastPair `json:"matrix"`
+}
+
+func (s *Server) paletteContrastHandler(c *gin.Context) {
+	var req PaletteContrastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.MinContrast == 0 {
+		req.MinContrast = wcagAA
+	}
+	if req.MinContrast < 1 || req.MinContrast > 21 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "minContrast must be between 1 and 21"})
+		return
+	}
+
+	palette, ok := s.resolvePaletteColors(c, req.Palette, req.PaletteID)
+	if !ok {
+		return
+	}
+
+	rgbas, err := parsePaletteRGBAs(palette)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaletteContrastResponse{
+		Palette:     palette,
+		MinContrast: req.MinContrast,
+		Matrix:      buildContrastMatrix(rgbas, req.MinContrast),
+	})
+}
+
+// Rows are foregrounds and columns are backgrounds, so matrix[i][j] describes
+// palette[i] used as text on palette[j].
+func buildContrastMatrix(rgbas []color.RGBA, minContrast float64) [][]ContrastPair {
+	matrix := make([][]ContrastPair, len(rgbas))
+	for i, fg := range rgbas {
+		matrix[i] = make([]ContrastPair, len(rgbas))
+		for j, bg := range rgbas {
+			ratio := contrastRatio(fg, bg)
+			pair := ContrastPair{
+				Foreground: rgbaToHex(fg),
+				Background: rgbaToHex(bg),
+				Ratio:      math.Round(ratio*100) / 100,
+				APCA:       math.Round(apcaContrast(fg, bg)*10) / 10,
+				AA:         ratio >= wcagAA,
+				AALarge:    ratio >= wcagAALarge,
+				AAA:        ratio >= wcagAAA,
+				AAALarge:   ratio >= wcagAA,
+			}
+			if i != j && ratio < minContrast {
+				if adjusted, ok := adjustForContrast(fg, bg, minContrast); ok {
+					pair.Suggestion = rgbaToHex(adjusted)
+				}
+			}
+			matrix[i][j] = pair
+		}
+	}
+	return matrix
+}
+
+func relativeLuminance(c color.RGBA) float64 {
+	r, g, b := rgbaToLinear(c)
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
+
+func contrastRatio(c1, c2 color.RGBA) float64 {
+	l1 := relativeLuminance(c1)
+	l2 := relativeLuminance(c2)
+	return (math.Max(l1, l2) + 0.05) / (math.Min(l1, l2) + 0.05)
+}
+
+// APCA-W3 0.0.98G-4g. Positive values are dark text on a light background,
+// negative values light text on a dark background.
+func apcaContrast(text, background color.RGBA) float64 {
+	const (
+		mainTRC   = 2.4
+		blkThrs   = 0.022
+		blkClmp   = 1.414
+		normBG    = 0.56
+		normTXT   = 0.57
+		revTXT    = 0.62
+		revBG     = 0.65
+		scale     = 1.14
+		loOffset  = 0.027
+		loClip    = 0.1
+		deltaYmin = 0.0005
+	)
+
+	screenY := func(c color.RGBA) float64 {
+		y := 0.2126729*math.Pow(float64(c.R)/255, mainTRC) +
+			0.7151522*math.Pow(float64(c.G)/255, mainTRC) +
+			0.0721750*math.Pow(float64(c.B)/255, mainTRC)
+		if y < blkThrs {
+			y += math.Pow(blkThrs-y, blkClmp)
+		}
+		return y
+	}
+
+	txtY := screenY(text)
+	bgY := screenY(background)
+	if math.Abs(bgY-txtY) < deltaYmin {
+		return 0
+	}
+
+	if bgY > txtY {
+		sapc := (math.Pow(bgY, normBG) - math.Pow(txtY, normTXT)) * scale
+		if sapc < loClip {
+			return 0
+		}
+		return (sapc - loOffset) * 100
+	}
+
+	sapc := (math.Pow(bgY, revBG) - math.Pow(txtY, revTXT)) * scale
+	if sapc > -loClip {
+		return 0
+	}
+	return (sapc + loOffset) * 100
+}
+
+// Like adjustForContrast in the Zig theme generator, this moves the foreground
+// away from the background until the contrast target is met. It walks OKLCH
+// lightness in both directions at once so hue and chroma survive and the
+// first hit is the smallest lightness change that passes.
+func adjustForContrast(fg, bg color.RGBA, minContrast float64) (color.RGBA, bool) {
+	if contrastRatio(fg, bg) >= minContrast {
+		return fg, true
+	}
+
+	lch := rgbaToOklch(fg)
+	for step := 1; step <= 100; step++ {
+		delta := float64(step) * 0.01
+		for _, l := range []float64{lch.L - delta, lch.L + delta} {
+			if l < 0 || l > 1 {
+				continue
+			}
+			candidate := oklchToRGBA(oklch{L: l, C: lch.C, H: lch.H})
+			if contrastRatio(candidate, bg) >= minContrast {
+				return candidate, true
+			}
+		}
+	}
+
+	return fg, false
+}
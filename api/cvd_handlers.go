@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var cvdDeficiencies = []string{"protanopia", "deuteranopia", "tritanopia", "achromatopsia"}
+
+// Machado, Oliveira and Fernandes (2009) at full severity, in linear RGB.
+var machadoMatrices = map[string][9]float64{
+	"protanopia": {
+		0.152286, 1.052583, -0.204868,
+		0.114503, 0.786281, 0.099216,
+		-0.003882, -0.048116, 1.051998,
+	},
+	"deuteranopia": {
+		0.367322, 0.860646, -0.227968,
+		0.280085, 0.672501, 0.047413,
+		-0.011820, 0.042940, 0.968881,
+	},
+}
+
+// Machado is not validated for tritanopia, so it uses Brettel, Viénot and
+// Mollon (1997): two projection half-planes split by the neutral axis.
+var brettelTritan = struct {
+	first  [9]float64
+	second [9]float64
+	normal [3]float64
+}{
+	first: [9]float64{
+		1.01277, 0.13548, -0.14826,
+		-0.01243, 0.86812, 0.14431,
+		0.07589, 0.80500, 0.11911,
+	},
+	second: [9]float64{
+		0.93678, 0.18979, -0.12657,
+		0.06154, 0.81526, 0.12320,
+		-0.37562, 1.12767, 0.24796,
+	},
+	normal: [3]float64{0.03901, -0.02788, -0.01113},
+}
+
+const defaultCVDThreshold = 10.0
+
+type PaletteSimulateRequest struct {
+	Palette      []Color  `json:"palette"`
+	PaletteID    string   `json:"paletteId"`
+	Deficiencies []string `json:"deficiencies"`
+	Threshold    float64  `json:"threshold"`
+}
+
+type CVDConflict struct {
+	Deficiency string  `json:"deficiency"`
+	First      int     `json:"first"`
+	Second     int     `json:"second"`
+	DeltaE     float64 `json:"deltaE"`
+}
+
+type PaletteSimulateResponse struct {
+	Palette     []Color            `json:"palette"`
+	Simulations map[string][]Color `json:"simulations"`
+	Conflicts   []CVDConflict      `json:"conflicts"`
+}
+
+func (s *Server) paletteSimulateHandler(c *gin.Context) {
+	var req PaletteSimulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Deficiencies) == 0 {
+		req.Deficiencies = cvdDeficiencies
+	}
+	for _, d := range req.Deficiencies {
+		if !isCVDDeficiency(d) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown deficiency %q", d)})
+			return
+		}
+	}
+	if req.Threshold <= 0 {
+		req.Threshold = defaultCVDThreshold
+	}
+
+	palette, ok := s.resolvePaletteColors(c, req.Palette, req.PaletteID)
+	if !ok {
+		return
+	}
+
+	rgbas, err := parsePaletteRGBAs(palette)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := PaletteSimulateResponse{
+		Palette:     palette,
+		Simulations: make(map[string][]Color, len(req.Deficiencies)),
+		Conflicts:   []CVDConflict{},
+	}
+
+	for _, deficiency := range req.Deficiencies {
+		simulated := make([]color.RGBA, len(rgbas))
+		colors := make([]Color, len(rgbas))
+		for i, rgba := range rgbas {
+			simulated[i] = simulateCVD(rgba, deficiency)
+			colors[i] = Color{Hex: rgbaToHex(simulated[i]), Name: palette[i].Name}
+		}
+		resp.Simulations[deficiency] = colors
+		resp.Conflicts = append(resp.Conflicts, findCVDConflicts(rgbas, simulated, deficiency, req.Threshold)...)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func isCVDDeficiency(name string) bool {
+	for _, d := range cvdDeficiencies {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Pairs that were already hard to tell apart are left alone; only pairs the
+// deficiency collapses below the threshold are reported.
+func findCVDConflicts(original, simulated []color.RGBA, deficiency string, threshold float64) []CVDConflict {
+	var conflicts []CVDConflict
+	for i := range original {
+		for j := i + 1; j < len(original); j++ {
+			if deltaE2000(rgbaToLab(original[i]), rgbaToLab(original[j])) < threshold {
+				continue
+			}
+			d := deltaE2000(rgbaToLab(simulated[i]), rgbaToLab(simulated[j]))
+			if d < threshold {
+				conflicts = append(conflicts, CVDConflict{
+					Deficiency: deficiency,
+					First:      i,
+					Second:     j,
+					DeltaE:     math.Round(d*100) / 100,
+				})
+			}
+		}
+	}
+	return conflicts
+}
+
+func simulateCVD(c color.RGBA, deficiency string) color.RGBA {
+	r, g, b := rgbaToLinear(c)
+
+	var m [9]float64
+	switch deficiency {
+	case "protanopia", "deuteranopia":
+		m = machadoMatrices[deficiency]
+	case "tritanopia":
+		m = brettelTritan.first
+		n := brettelTritan.normal
+		if r*n[0]+g*n[1]+b*n[2] < 0 {
+			m = brettelTritan.second
+		}
+	case "achromatopsia":
+		y := 0.2126*r + 0.7152*g + 0.0722*b
+		out := linearToRGBA(y, y, y)
+		out.A = c.A
+		return out
+	default:
+		return c
+	}
+
+	out := linearToRGBA(
+		m[0]*r+m[1]*g+m[2]*b,
+		m[3]*r+m[4]*g+m[5]*b,
+		m[6]*r+m[7]*g+m[8]*b,
+	)
+	out.A = c.A
+	return out
+}
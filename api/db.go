@@ -1,11 +1,16 @@
 package main
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
+	sqlitedriver "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -14,6 +19,27 @@ import (
 
 var DB *gorm.DB
 
+// Delta E filters call ciede2000 in SQL. Postgres gets it from a migration;
+// SQLite cannot define functions in SQL, so deltaE2000 is registered instead.
+func init() {
+	sqlitedriver.MustRegisterDeterministicScalarFunction("ciede2000", 6, sqliteCIEDE2000)
+}
+
+func sqliteCIEDE2000(_ *sqlitedriver.FunctionContext, args []driver.Value) (driver.Value, error) {
+	var v [6]float64
+	for i, arg := range args {
+		switch n := arg.(type) {
+		case float64:
+			v[i] = n
+		case int64:
+			v[i] = float64(n)
+		default:
+			return nil, fmt.Errorf("ciede2000: argument %d is not a number", i+1)
+		}
+	}
+	return deltaE2000(cielab{v[0], v[1], v[2]}, cielab{v[3], v[4], v[5]}), nil
+}
+
 type DatabaseConfig struct {
 	Host     string
 	Port     string
@@ -24,19 +50,31 @@ type DatabaseConfig struct {
 }
 
 func InitDatabase() error {
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: Could not load .env file:", err)
+	if err := openDatabase(); err != nil {
+		return err
 	}
 
-	config := getDatabaseConfig()
+	if err := runMigrations(); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		config.Host, config.Port, config.User, config.Password, config.Database, config.SSLMode)
+	log.Println("Database connected and migrated successfully")
+	return nil
+}
 
-	fmt.Println(config.Password)
+// openDatabase connects DB without touching the schema, which the migrate
+// command needs. DB_DRIVER selects postgres (the default) or sqlite.
+func openDatabase() error {
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: Could not load .env file:", err)
+	}
+
+	dialector, err := databaseDialector(getEnv("DB_DRIVER", "postgres"))
+	if err != nil {
+		return err
+	}
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+	DB, err = gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
@@ -55,16 +93,34 @@ func InitDatabase() error {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	if err := runMigrations(); err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
-	}
+	return nil
+}
 
-	if err := initializeDemoUser(); err != nil {
-		log.Printf("Warning: Failed to initialize demo user: %v", err)
+func databaseDialector(driver string) (gorm.Dialector, error) {
+	switch driver {
+	case "postgres":
+		config := getDatabaseConfig()
+		dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			config.Host, config.Port, config.User, config.Password, config.Database, config.SSLMode)
+		return postgres.Open(dsn), nil
+	case "sqlite":
+		path := getEnv("SQLITE_PATH", "data/image-to-palette.db")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+		// The pure-Go SQLite port keeps the binary buildable with CGO_ENABLED=0.
+		return sqlite.Open(sqliteDSN(path)), nil
+	default:
+		return nil, fmt.Errorf("unknown DB_DRIVER %q (expected postgres or sqlite)", driver)
 	}
+}
 
-	log.Println("Database connected and migrated successfully")
-	return nil
+// sqliteDSN enables foreign keys, which SQLite leaves off by default, and
+// makes concurrent requests wait for each other instead of failing: WAL lets
+// readers run alongside the single writer, and immediate transactions take
+// the write lock up front so busy_timeout applies to them.
+func sqliteDSN(path string) string {
+	return "file:" + path + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
 }
 
 func getDatabaseConfig() DatabaseConfig {
@@ -85,22 +141,24 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-func runMigrations() error {
-	return DB.AutoMigrate(&Palette{}, &User{}, &Workspace{})
-}
-
-func initializeDemoUser() error {
-	if DB == nil {
-		return fmt.Errorf("database not available")
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+		log.Printf("Warning: invalid integer for %s: %q, using %d", key, value, defaultValue)
 	}
+	return defaultValue
+}
 
-	_, err := createDemoUserIfNotExists()
+func runMigrations() error {
+	migrations, err := embeddedMigrations(DB.Dialector.Name())
 	if err != nil {
-		return fmt.Errorf("failed to initialize demo user: %w", err)
+		return err
 	}
 
-	log.Println("Demo user initialized successfully")
-	return nil
+	_, err = migrateUp(DB, migrations)
+	return err
 }
 
 func CloseDatabase() error {
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxTags          = 20
+	maxTagLength     = 50
+	defaultColorDist = 10.0
+)
+
+type ListFilter struct {
+	Tags     []string
+	Query    string
+	From     *time.Time
+	To       *time.Time
+	Color    *cielab
+	ColorHex string
+	DeltaE   float64
+
+	CollectionID uint
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tags must be at most %d characters", maxTagLength)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > maxTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxTags)
+	}
+
+	return normalized, nil
+}
+
+func encodeTags(tags []string) string {
+	if len(tags) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(tags)
+	return string(data)
+}
+
+func decodeTags(data string) []string {
+	tags := []string{}
+	if data != "" {
+		json.Unmarshal([]byte(data), &tags)
+	}
+	return tags
+}
+
+func parseFilterTime(value string, endOfDay bool) (*time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("dates must be RFC 3339 or YYYY-MM-DD")
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+// Supported query parameters: tag (repeatable, all must match), q (full-text
+// search on the name), from and to (creation date range), color with an
+// optional deltaE for "contains a color close to this one", and collection to
+// list only members of one collection.
+func parseListFilter(c *gin.Context) (ListFilter, error) {
+	var filter ListFilter
+
+	tags, err := normalizeTags(c.QueryArray("tag"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Tags = tags
+	filter.Query = strings.TrimSpace(c.Query("q"))
+
+	if s := c.Query("collection"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("collection must be a collection ID")
+		}
+		filter.CollectionID = uint(id)
+	}
+
+	if from := c.Query("from"); from != "" {
+		if filter.From, err = parseFilterTime(from, false); err != nil {
+			return filter, err
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if filter.To, err = parseFilterTime(to, true); err != nil {
+			return filter, err
+		}
+	}
+
+	if hex := c.Query("color"); hex != "" {
+		rgba, err := hexToRGBA(hex)
+		if err != nil {
+			return filter, fmt.Errorf("color must be a hex value like #RRGGBB")
+		}
+		lab := rgbaToLab(rgba)
+		filter.Color = &lab
+		filter.ColorHex = rgbaToHex(rgba)
+		filter.DeltaE = defaultColorDist
+
+		if s := c.Query("deltaE"); s != "" {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil || v < 0 {
+				return filter, fmt.Errorf("deltaE must be a non-negative number")
+			}
+			filter.DeltaE = v
+		}
+	}
+
+	return filter, nil
+}
+
+// listSource describes a table applyListFilter works on: where the color
+// list lives inside json_data ("" when json_data is the list itself) and
+// which table holds its indexed colors.
+type listSource struct {
+	table       string
+	colorsKey   string
+	colorTable  string
+	colorColumn string
+}
+
+var (
+	paletteList   = listSource{table: "palettes", colorTable: "palette_colors", colorColumn: "palette_id"}
+	workspaceList = listSource{table: "workspaces", colorsKey: "colors", colorTable: "workspace_colors", colorColumn: "workspace_id"}
+)
+
+// On Postgres, tag containment, name search and exact color matches are
+// served by the GIN indexes from the 0004 migration. SQLite has no such
+// indexes, so the same conditions are written with json_each and LIKE and
+// scan the user's rows. A non-zero Delta E goes through the color index on
+// both.
+func applyListFilter(query *gorm.DB, filter ListFilter, source listSource) *gorm.DB {
+	if query.Dialector.Name() == "sqlite" {
+		query = applySQLiteListFilter(query, filter, source.colorsKey)
+	} else {
+		query = applyPostgresListFilter(query, filter, source.colorsKey)
+	}
+	if filter.Color != nil && filter.DeltaE > 0 {
+		query = applyColorDistanceFilter(query, filter, source)
+	}
+
+	// Times are compared as text on SQLite, so they must use the same zone as
+	// the stored values.
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", filter.To.UTC())
+	}
+	return query
+}
+
+func applyPostgresListFilter(query *gorm.DB, filter ListFilter, colorsKey string) *gorm.DB {
+	if len(filter.Tags) > 0 {
+		query = query.Where("tags @> ?::jsonb", encodeTags(filter.Tags))
+	}
+	if filter.Query != "" {
+		query = query.Where("to_tsvector('simple', name) @@ plainto_tsquery('simple', ?)", filter.Query)
+	}
+	if filter.Color != nil && filter.DeltaE == 0 {
+		upper := colorContainment(filter.ColorHex, colorsKey)
+		lower := colorContainment(strings.ToLower(filter.ColorHex), colorsKey)
+		query = query.Where("(json_data @> ?::jsonb OR json_data @> ?::jsonb)", upper, lower)
+	}
+	return query
+}
+
+// Name search matches every word of q as a substring, the closest LIKE gets
+// to plainto_tsquery.
+func applySQLiteListFilter(query *gorm.DB, filter ListFilter, colorsKey string) *gorm.DB {
+	for _, tag := range filter.Tags {
+		query = query.Where("EXISTS (SELECT 1 FROM json_each(tags) WHERE json_each.value = ?)", tag)
+	}
+	for _, word := range strings.Fields(filter.Query) {
+		query = query.Where("name LIKE ? ESCAPE '\\'", "%"+escapeLike(word)+"%")
+	}
+	if filter.Color != nil && filter.DeltaE == 0 {
+		colorsPath := "$"
+		if colorsKey != "" {
+			colorsPath = "$." + colorsKey
+		}
+		query = query.Where("EXISTS (SELECT 1 FROM json_each(json_data, ?) WHERE upper(json_extract(json_each.value, '$.hex')) = ?)",
+			colorsPath, strings.ToUpper(filter.ColorHex))
+	}
+	return query
+}
+
+// applyColorDistanceFilter keeps rows with a color within filter.DeltaE. The
+// lightness range comes from maxLightnessWeight and is what the index on l
+// serves; ciede2000 then decides exactly.
+func applyColorDistanceFilter(query *gorm.DB, filter ListFilter, source listSource) *gorm.DB {
+	target := *filter.Color
+	slack := filter.DeltaE * maxLightnessWeight
+	condition := fmt.Sprintf("EXISTS (SELECT 1 FROM %[1]s WHERE %[1]s.%[2]s = %[3]s.id"+
+		" AND %[1]s.l BETWEEN ? AND ?"+
+		" AND ciede2000(?, ?, ?, %[1]s.l, %[1]s.a, %[1]s.b) <= ?)",
+		source.colorTable, source.colorColumn, source.table)
+	return query.Where(condition, target.L-slack, target.L+slack, target.L, target.A, target.B, filter.DeltaE)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func colorContainment(hex, colorsKey string) string {
+	var doc any = []Color{{Hex: hex}}
+	if colorsKey != "" {
+		doc = map[string]any{colorsKey: doc}
+	}
+	data, _ := json.Marshal(doc)
+	return string(data)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errGalleryPaletteNotFound = errors.New("published palette not found")
+	errPaletteModerated       = errors.New("palette was removed from the gallery by a moderator")
+)
+
+type GalleryPaletteData struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Palette     []Color   `json:"palette"`
+	Tags        []string  `json:"tags"`
+	Author      string    `json:"author"`
+	LikeCount   int       `json:"likeCount"`
+	Liked       bool      `json:"liked"`
+	PublishedAt time.Time `json:"publishedAt"`
+}
+
+type GetGalleryResponse struct {
+	Palettes   []GalleryPaletteData `json:"palettes"`
+	NextCursor string               `json:"nextCursor,omitempty"`
+}
+
+func (s *Server) publishPaletteHandler(c *gin.Context) {
+	s.setPalettePublished(c, true)
+}
+
+func (s *Server) unpublishPaletteHandler(c *gin.Context) {
+	s.setPalettePublished(c, false)
+}
+
+func (s *Server) setPalettePublished(c *gin.Context, published bool) {
+	paletteID := c.Param("id")
+	if paletteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Palette ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to publish palettes"})
+		return
+	}
+
+	palette, err := s.setUserPalettePublished(userID, paletteID, published)
+	if err != nil {
+		switch {
+		case errors.Is(err, errPaletteNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errSystemPalette), errors.Is(err, errPaletteModerated):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update palette"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, palette)
+}
+
+// Supports sort=recent (default, by publish date) or sort=popular (by likes),
+// the same tag/q/color filters as GET /palettes, and cursor pagination.
+// Authentication is optional and only used to mark palettes the caller liked.
+func (s *Server) getGalleryHandler(c *gin.Context) {
+	sort := sortByPublished
+	switch c.DefaultQuery("sort", "recent") {
+	case "recent":
+	case "popular":
+		sort = sortByLikes
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be recent or popular"})
+		return
+	}
+
+	filter, err := parseListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := parsePageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, userID := isAuthenticated(c)
+
+	palettes, nextCursor, err := s.getGalleryPalettes(userID, filter, page, sort)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch gallery"})
+		return
+	}
+
+	c.JSON(http.StatusOK, GetGalleryResponse{Palettes: palettes, NextCursor: nextCursor})
+}
+
+func (s *Server) likePaletteHandler(c *gin.Context) {
+	s.setPaletteLiked(c, true)
+}
+
+func (s *Server) unlikePaletteHandler(c *gin.Context) {
+	s.setPaletteLiked(c, false)
+}
+
+func (s *Server) setPaletteLiked(c *gin.Context, liked bool) {
+	paletteID := c.Param("id")
+	if paletteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Palette ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to like palettes"})
+		return
+	}
+
+	likeCount, err := s.Gallery.SetLike(userID, paletteID, liked)
+	if err != nil {
+		if errors.Is(err, errGalleryPaletteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update like"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"liked": liked, "likeCount": likeCount})
+}
+
+func (s *Server) forkPaletteHandler(c *gin.Context) {
+	paletteID := c.Param("id")
+	if paletteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Palette ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to fork palettes"})
+		return
+	}
+
+	palette, err := s.forkGalleryPalette(userID, paletteID)
+	if err != nil {
+		if errors.Is(err, errGalleryPaletteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fork palette"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, palette)
+}
+
+func (s *Server) moderateGalleryPaletteHandler(c *gin.Context) {
+	paletteID := c.Param("id")
+	if paletteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Palette ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	if !s.isAdminUser(userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+
+	if err := s.Gallery.Moderate(paletteID); err != nil {
+		if errors.Is(err, errGalleryPaletteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unpublish palette"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Palette removed from gallery"})
+}
+
+// Publishing is not an edit of the palette itself, so updated_at (and with it
+// the ETag used for concurrency checks) is left alone.
+func (s *Server) setUserPalettePublished(userID uint, paletteID string, published bool) (*PaletteData, error) {
+	palette, err := s.Palettes.Get(userID, paletteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if palette.IsSystem {
+		return nil, errSystemPalette
+	}
+	if published && palette.ModeratedAt != nil {
+		return nil, errPaletteModerated
+	}
+
+	if err := s.Palettes.SetPublished(palette, published); err != nil {
+		return nil, err
+	}
+
+	var colors []Color
+	if err := json.Unmarshal([]byte(palette.JsonData), &colors); err != nil {
+		return nil, fmt.Errorf("failed to parse palette data")
+	}
+
+	data := toPaletteData(*palette, colors)
+	return &data, nil
+}
+
+func (s *Server) getGalleryPalettes(userID uint, filter ListFilter, page PageParams, sort pageSort) ([]GalleryPaletteData, string, error) {
+	dbPalettes, nextCursor, err := s.Gallery.List(filter, page, sort)
+	if err != nil {
+		return nil, "", err
+	}
+
+	liked := map[uint]bool{}
+	if userID != 0 && len(dbPalettes) > 0 {
+		ids := make([]uint, len(dbPalettes))
+		for i, p := range dbPalettes {
+			ids[i] = p.ID
+		}
+
+		if liked, err = s.Gallery.Liked(userID, ids); err != nil {
+			return nil, "", err
+		}
+	}
+
+	palettes := make([]GalleryPaletteData, 0, len(dbPalettes))
+	for _, dbPalette := range dbPalettes {
+		var colors []Color
+		json.Unmarshal([]byte(dbPalette.JsonData), &colors)
+
+		author := ""
+		if dbPalette.User != nil {
+			author = dbPalette.User.Name
+		}
+
+		palettes = append(palettes, GalleryPaletteData{
+			ID:          fmt.Sprintf("%d", dbPalette.ID),
+			Name:        dbPalette.Name,
+			Palette:     withColorNames(colors),
+			Tags:        decodeTags(dbPalette.Tags),
+			Author:      author,
+			LikeCount:   dbPalette.LikeCount,
+			Liked:       liked[dbPalette.ID],
+			PublishedAt: *dbPalette.PublishedAt,
+		})
+	}
+
+	return palettes, nextCursor, nil
+}
+
+func (s *Server) forkGalleryPalette(userID uint, paletteID string) (*PaletteData, error) {
+	source, err := s.Gallery.Get(paletteID)
+	if err != nil {
+		return nil, err
+	}
+
+	fork := Palette{
+		UserID:       &userID,
+		Name:         source.Name,
+		JsonData:     source.JsonData,
+		Tags:         source.Tags,
+		ForkedFromID: &source.ID,
+	}
+	if err := s.Palettes.Create(&fork); err != nil {
+		return nil, err
+	}
+
+	var colors []Color
+	if err := json.Unmarshal([]byte(fork.JsonData), &colors); err != nil {
+		return nil, fmt.Errorf("failed to parse palette data")
+	}
+
+	data := toPaletteData(fork, colors)
+	return &data, nil
+}
@@ -13,7 +13,8 @@ import (
 )
 
 type Color struct {
-	Hex string `json:"hex"`
+	Hex  string `json:"hex"`
+	Name string `json:"name,omitempty"`
 }
 
 type ExtractResult struct {
@@ -89,6 +90,12 @@ func applyPaletteHandler(c *gin.Context) {
 		}
 	}
 
+	simulate := c.PostForm("simulate")
+	if simulate != "" && !isCVDDeficiency(simulate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "simulate must be one of protanopia, deuteranopia, tritanopia or achromatopsia"})
+		return
+	}
+
 	img, _, err := image.Decode(file)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to decode image: " + err.Error()})
@@ -96,6 +103,9 @@ func applyPaletteHandler(c *gin.Context) {
 	}
 
 	out := processImageWithShepardsMethod(img, paletteRGBAs, luminosity, nearest, power, maxDistanceSq)
+	if simulate != "" {
+		simulateImageCVD(out, simulate)
+	}
 
 	var buf bytes.Buffer
 	if err := png.Encode(&buf, out); err != nil {
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var harmonyOffsets = map[string][]float64{
+	"complementary":       {0, 180},
+	"analogous":           {-30, 0, 30},
+	"triadic":             {0, 120, 240},
+	"tetradic":            {0, 90, 180, 270},
+	"split-complementary": {0, 150, 210},
+}
+
+// Each seed yields up to four colors, so 64 seeds keep the response within
+// a few hundred.
+type PaletteHarmonyRequest struct {
+	Seeds  []Color `json:"seeds" binding:"required,min=1,max=64"`
+	Scheme string  `json:"scheme" binding:"required"`
+	Space  string  `json:"space"`
+}
+
+type PaletteHarmonyResponse struct {
+	Name    string  `json:"name"`
+	Palette []Color `json:"palette"`
+}
+
+func paletteHarmonyHandler(c *gin.Context) {
+	var req PaletteHarmonyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Space == "" {
+		req.Space = "oklch"
+	}
+
+	palette, err := generateHarmony(req.Seeds, req.Scheme, req.Space)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaletteHarmonyResponse{
+		Name:    fmt.Sprintf("%s harmony", strings.ReplaceAll(req.Scheme, "-", " ")),
+		Palette: palette,
+	})
+}
+
+func generateHarmony(seeds []Color, scheme, space string) ([]Color, error) {
+	offsets, ok := harmonyOffsets[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unknown harmony scheme %q", scheme)
+	}
+	if space != "oklch" && space != "hsl" {
+		return nil, fmt.Errorf("space must be oklch or hsl")
+	}
+
+	rgbas, err := parsePaletteRGBAs(seeds)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	palette := make([]Color, 0, len(rgbas)*len(offsets))
+	for _, seed := range rgbas {
+		for _, offset := range offsets {
+			hex := rgbaToHex(rotateHue(seed, offset, space))
+			if seen[hex] {
+				continue
+			}
+			seen[hex] = true
+			palette = append(palette, Color{Hex: hex})
+		}
+	}
+
+	return palette, nil
+}
+
+func rotateHue(c color.RGBA, degrees float64, space string) color.RGBA {
+	if degrees == 0 {
+		return c
+	}
+
+	if space == "hsl" {
+		h := rgbaToHSL(c)
+		h.H += degrees
+		return hslToRGBA(h)
+	}
+
+	lch := rgbaToOklch(c)
+	lch.H = math.Mod(lch.H+degrees+360, 360)
+	return oklchToRGBA(lch)
+}
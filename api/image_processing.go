@@ -58,3 +58,34 @@ func processImageWithShepardsMethod(
 	wg.Wait()
 	return out
 }
+
+func simulateImageCVD(img *image.RGBA, deficiency string) {
+	bounds := img.Bounds()
+	height := bounds.Dy()
+
+	numWorkers := max(min(runtime.GOMAXPROCS(0), height), 1)
+	rowsPerWorker := (height + numWorkers - 1) / numWorkers
+
+	var wg sync.WaitGroup
+	for workerID := range numWorkers {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+
+			startY := bounds.Min.Y + id*rowsPerWorker
+			endY := min(startY+rowsPerWorker, bounds.Max.Y)
+
+			for y := startY; y < endY; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					pixel := img.RGBAAt(x, y)
+					if pixel.A == 0 {
+						continue
+					}
+					img.SetRGBA(x, y, simulateCVD(pixel, deficiency))
+				}
+			}
+		}(workerID)
+	}
+
+	wg.Wait()
+}
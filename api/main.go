@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 	"time"
 
 	_ "image/gif"
@@ -14,6 +15,13 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if err := InitDatabase(); err != nil {
 		log.Printf("Failed to initialize database: %v", err)
 		log.Println("Continuing without database functionality...")
@@ -25,38 +33,116 @@ func main() {
 		}
 	}()
 
+	if err := InitBlobStore(); err != nil {
+		log.Printf("Failed to initialize blob store: %v", err)
+		log.Println("Continuing without workspace image storage...")
+	}
+
+	if DB != nil && Blobs != nil {
+		if err := migrateWorkspaceImages(); err != nil {
+			log.Printf("Failed to migrate workspace images: %v", err)
+		}
+	}
+
+	if DB != nil {
+		if err := indexMissingColors(); err != nil {
+			log.Printf("Failed to index colors: %v", err)
+		}
+	}
+
+	server := newServer(DB, Blobs)
+
+	if DB != nil {
+		if err := server.initializeDemoUser(); err != nil {
+			log.Printf("Warning: Failed to initialize demo user: %v", err)
+		}
+		startMaintenance()
+	}
+
+	router := newRouter(server)
+
+	log.Println("Starting server on :8088")
+	router.Run(":8088")
+}
+
+func newRouter(s *Server) *gin.Engine {
 	router := gin.Default()
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"},
-		AllowMethods:     []string{"POST", "GET", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"POST", "GET", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", sharePasswordHeader},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	router.POST("/auth/register", registerHandler)
-	router.POST("/auth/login", loginHandler)
-	router.POST("/auth/demo-login", demoLoginHandler)
+	router.POST("/auth/register", s.registerHandler)
+	router.POST("/auth/login", s.loginHandler)
+	router.POST("/auth/demo-login", s.demoLoginHandler)
 
 	auth := router.Group("/auth")
 	auth.Use(authMiddleware())
 	{
-		auth.GET("/me", getMeHandler)
-		auth.POST("/change-password", changePasswordHandler)
+		auth.GET("/me", s.getMeHandler)
+		auth.POST("/change-password", s.changePasswordHandler)
 	}
 
-	router.GET("/palettes", getPalettesHandler)
-	router.POST("/palettes", savePaletteHandler)
-	router.DELETE("/palettes/:id", deletePaletteHandler)
+	router.GET("/palettes", s.getPalettesHandler)
+	router.POST("/palettes", s.savePaletteHandler)
+	router.POST("/palettes/scales", s.paletteScalesHandler)
+	router.POST("/palettes/harmony", paletteHarmonyHandler)
+	router.POST("/palettes/names", nameColorsHandler)
+	router.POST("/palettes/contrast", s.paletteContrastHandler)
+	router.POST("/palettes/simulate", s.paletteSimulateHandler)
+	router.POST("/palettes/compare", s.paletteCompareHandler)
+	router.POST("/palettes/reduce", s.paletteReduceHandler)
+	router.PUT("/palettes/:id", s.updatePaletteHandler)
+	router.PATCH("/palettes/:id", s.updatePaletteHandler)
+	router.DELETE("/palettes/:id", s.deletePaletteHandler)
+	router.GET("/palettes/:id/revisions", s.getPaletteRevisionsHandler)
+	router.POST("/palettes/:id/revert/:rev", s.revertPaletteHandler)
+	router.PUT("/palettes/:id/colors/:index/name", s.renamePaletteColorHandler)
+	router.POST("/palettes/:id/publish", s.publishPaletteHandler)
+	router.DELETE("/palettes/:id/publish", s.unpublishPaletteHandler)
+	router.POST("/palettes/:id/share", s.sharePaletteHandler)
+	router.DELETE("/palettes/:id/share", s.removePaletteShareHandler)
 
-	router.GET("/workspaces", getWorkspacesHandler)
-	router.POST("/workspaces", saveWorkspaceHandler)
-	router.DELETE("/workspaces/:id", deleteWorkspaceHandler)
-	router.POST("/workspaces/:id/share", shareWorkspaceHandler)
-	router.DELETE("/workspaces/:id/share", removeWorkspaceShareHandler)
-	router.GET("/shared", getSharedWorkspaceHandler)
+	router.GET("/gallery", s.getGalleryHandler)
+	router.POST("/gallery/:id/like", s.likePaletteHandler)
+	router.DELETE("/gallery/:id/like", s.unlikePaletteHandler)
+	router.POST("/gallery/:id/fork", s.forkPaletteHandler)
+	router.DELETE("/gallery/:id", s.moderateGalleryPaletteHandler)
+
+	router.GET("/workspaces", s.getWorkspacesHandler)
+	router.POST("/workspaces", s.saveWorkspaceHandler)
+	router.GET("/workspaces/:id", s.getWorkspaceHandler)
+	router.PUT("/workspaces/:id", s.updateWorkspaceHandler)
+	router.GET("/workspaces/:id/image", s.getWorkspaceImageHandler)
+	router.GET("/workspaces/:id/thumbnail", s.getWorkspaceThumbnailHandler)
+	router.DELETE("/workspaces/:id", s.deleteWorkspaceHandler)
+	router.PUT("/workspaces/:id/tags", s.updateWorkspaceTagsHandler)
+	router.POST("/workspaces/:id/share", s.shareWorkspaceHandler)
+	router.DELETE("/workspaces/:id/share", s.removeWorkspaceShareHandler)
+	router.GET("/shared/:token", s.getSharedWorkspaceHandler)
+	router.POST("/shared/:token/fork", s.forkSharedWorkspaceHandler)
+	router.GET("/shared/palettes/:token", s.getSharedPaletteHandler)
+
+	router.GET("/collections", s.getCollectionsHandler)
+	router.POST("/collections", s.createCollectionHandler)
+	router.GET("/collections/:id", s.getCollectionHandler)
+	router.PUT("/collections/:id", s.renameCollectionHandler)
+	router.DELETE("/collections/:id", s.deleteCollectionHandler)
+	router.PUT("/collections/:id/palettes/:paletteId", s.addCollectionPaletteHandler)
+	router.DELETE("/collections/:id/palettes/:paletteId", s.removeCollectionPaletteHandler)
+	router.PUT("/collections/:id/workspaces/:workspaceId", s.addCollectionWorkspaceHandler)
+	router.DELETE("/collections/:id/workspaces/:workspaceId", s.removeCollectionWorkspaceHandler)
+
+	router.GET("/trash", s.getTrashHandler)
+	router.POST("/trash/:type/:id/restore", s.restoreTrashItemHandler)
+
+	router.GET("/account/export", s.exportAccountHandler)
+	router.POST("/account/import", s.importAccountHandler)
 
 	router.POST("/apply-palette", applyPaletteHandler)
 
@@ -64,6 +150,5 @@ func main() {
 	router.GET("/wallhaven/w/:id", wallhavenGetWallpaperHandler)
 	router.GET("/wallhaven/download", wallhavenDownloadHandler)
 
-	log.Println("Starting server on :8088")
-	router.Run(":8088")
+	return router
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+var maintenanceTasks = []struct {
+	name string
+	run  func() error
+}{
+	{"palette revisions", purgeExpiredPaletteRevisions},
+	{"expired share links", purgeExpiredShareLinks},
+	{"trash", purgeExpiredTrash},
+}
+
+func startMaintenance() {
+	interval := time.Duration(getEnvInt("MAINTENANCE_INTERVAL_MINUTES", 60)) * time.Minute
+	if interval <= 0 {
+		log.Println("Maintenance tasks disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			runMaintenance()
+			<-ticker.C
+		}
+	}()
+}
+
+func runMaintenance() {
+	for _, task := range maintenanceTasks {
+		if err := task.run(); err != nil {
+			log.Printf("Maintenance task %q failed: %v", task.name, err)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+// Each database driver has its own directory under migrations/, named after
+// the GORM dialect. Versions must stay in step across directories.
+//
+// Migration files are named <version>_<name>.up.sql and
+// <version>_<name>.down.sql; every version needs both.
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// migrationLockKey is an arbitrary constant for pg_advisory_lock, so that
+// instances starting together do not apply the same migration twice.
+const migrationLockKey = 7218436001
+
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// loadMigrations reads the migrations in dir, ordered by version.
+func loadMigrations(fsys fs.FS, dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files with different names (%s, %s)", version, m.Name, match[2])
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func embeddedMigrations(dialect string) ([]migration, error) {
+	return loadMigrations(migrationFiles, path.Join("migrations", dialect))
+}
+
+func ensureMigrationTable(db *gorm.DB) error {
+	timestampType := "timestamptz"
+	if db.Dialector.Name() == "sqlite" {
+		timestampType = "datetime"
+	}
+
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		applied_at ` + timestampType + ` NOT NULL
+	)`).Error
+}
+
+func appliedMigrations(db *gorm.DB) (map[int]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// withMigrationLock runs fn on a single connection holding the migration lock.
+// Only Postgres has advisory locks; other databases run fn directly.
+func withMigrationLock(db *gorm.DB, fn func(*gorm.DB) error) error {
+	if db.Dialector.Name() != "postgres" {
+		return fn(db)
+	}
+
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+			return err
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey)
+
+		return fn(conn)
+	})
+}
+
+// migrateUp applies every pending migration in order, each in its own
+// transaction, and returns how many were applied.
+func migrateUp(db *gorm.DB, migrations []migration) (int, error) {
+	count := 0
+	err := withMigrationLock(db, func(conn *gorm.DB) error {
+		if err := ensureMigrationTable(conn); err != nil {
+			return err
+		}
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(m.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: tx.NowFunc()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			log.Printf("Applied migration %d_%s", m.Version, m.Name)
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// migrateDown rolls back the latest steps applied migrations and returns how
+// many were rolled back.
+func migrateDown(db *gorm.DB, migrations []migration, steps int) (int, error) {
+	count := 0
+	err := withMigrationLock(db, func(conn *gorm.DB) error {
+		if err := ensureMigrationTable(conn); err != nil {
+			return err
+		}
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(m.Down).Error; err != nil {
+					return err
+				}
+				return tx.Where("version = ?", m.Version).Delete(&schemaMigration{}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of migration %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			log.Printf("Rolled back migration %d_%s", m.Version, m.Name)
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+func migrationStatus(db *gorm.DB, migrations []migration) ([]MigrationStatus, error) {
+	if err := ensureMigrationTable(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		status[i] = MigrationStatus{Version: m.Version, Name: m.Name}
+		if row, ok := applied[m.Version]; ok {
+			appliedAt := row.AppliedAt
+			status[i].AppliedAt = &appliedAt
+		}
+	}
+	return status, nil
+}
+
+// runMigrateCommand implements "migrate up", "migrate down [steps]" and
+// "migrate status" on the server binary.
+func runMigrateCommand(args []string) error {
+	const usage = "usage: migrate up | down [steps] | status"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	steps := 1
+	switch {
+	case (args[0] == "up" || args[0] == "status") && len(args) == 1:
+	case args[0] == "down" && len(args) <= 2:
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("steps must be a positive number")
+			}
+			steps = n
+		}
+	default:
+		return errors.New(usage)
+	}
+
+	if err := openDatabase(); err != nil {
+		return err
+	}
+	defer CloseDatabase()
+
+	migrations, err := embeddedMigrations(DB.Dialector.Name())
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := migrateUp(DB, migrations)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations\n", count)
+	case "down":
+		count, err := migrateDown(DB, migrations, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %d migrations\n", count)
+	case "status":
+		status, err := migrationStatus(DB, migrations)
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, state)
+		}
+	}
+
+	return nil
+}
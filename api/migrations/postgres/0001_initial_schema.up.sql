@@ -0,0 +1,40 @@
+-- Baseline schema, exactly what AutoMigrate created before versioned
+-- migrations existed. Everything is IF NOT EXISTS so databases set up by
+-- AutoMigrate adopt this version unchanged; everything added since comes in
+-- the later migrations, which likewise tolerate columns AutoMigrate added.
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    name varchar(255) NOT NULL,
+    email varchar(255) NOT NULL,
+    password_hash varchar(255) NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS palettes (
+    id bigserial PRIMARY KEY,
+    user_id bigint,
+    name varchar(255) NOT NULL,
+    json_data jsonb NOT NULL,
+    is_system boolean DEFAULT false,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_users_palettes FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_palettes_user_id ON palettes (user_id);
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    id bigserial PRIMARY KEY,
+    user_id bigint,
+    name varchar(255) NOT NULL,
+    json_data jsonb NOT NULL,
+    image_data text NOT NULL,
+    share_token varchar(64),
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_workspaces_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_workspaces_user_id ON workspaces (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspaces_share_token ON workspaces (share_token);
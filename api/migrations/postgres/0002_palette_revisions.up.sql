@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS palette_revisions (
+    id bigserial PRIMARY KEY,
+    palette_id bigint NOT NULL,
+    revision bigint NOT NULL,
+    name varchar(255) NOT NULL,
+    json_data jsonb NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_palette_revision ON palette_revisions (palette_id, revision);
+CREATE INDEX IF NOT EXISTS idx_palette_revisions_created_at ON palette_revisions (created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE workspaces DROP COLUMN IF EXISTS tags;
+ALTER TABLE palettes DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,2 @@
+ALTER TABLE palettes ADD COLUMN IF NOT EXISTS tags jsonb NOT NULL DEFAULT '[]';
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS tags jsonb NOT NULL DEFAULT '[]';
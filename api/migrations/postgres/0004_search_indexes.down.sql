@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_workspaces_json_data;
+DROP INDEX IF EXISTS idx_workspaces_name_search;
+DROP INDEX IF EXISTS idx_workspaces_tags;
+DROP INDEX IF EXISTS idx_palettes_json_data;
+DROP INDEX IF EXISTS idx_palettes_name_search;
+DROP INDEX IF EXISTS idx_palettes_tags;
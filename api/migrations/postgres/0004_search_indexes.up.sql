@@ -0,0 +1,9 @@
+-- GIN indexes behind tag containment (@>), name search (to_tsvector) and
+-- color filters on the stored JSON.
+
+CREATE INDEX IF NOT EXISTS idx_palettes_tags ON palettes USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_palettes_name_search ON palettes USING GIN (to_tsvector('simple', name));
+CREATE INDEX IF NOT EXISTS idx_palettes_json_data ON palettes USING GIN (json_data jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_workspaces_tags ON workspaces USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_workspaces_name_search ON workspaces USING GIN (to_tsvector('simple', name));
+CREATE INDEX IF NOT EXISTS idx_workspaces_json_data ON workspaces USING GIN (json_data jsonb_path_ops);
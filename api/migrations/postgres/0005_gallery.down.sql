@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS palette_likes;
+ALTER TABLE palettes DROP COLUMN IF EXISTS forked_from_id;
+ALTER TABLE palettes DROP COLUMN IF EXISTS like_count;
+ALTER TABLE palettes DROP COLUMN IF EXISTS published_at;
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin boolean DEFAULT false;
+
+ALTER TABLE palettes ADD COLUMN IF NOT EXISTS published_at timestamptz;
+ALTER TABLE palettes ADD COLUMN IF NOT EXISTS like_count bigint NOT NULL DEFAULT 0;
+ALTER TABLE palettes ADD COLUMN IF NOT EXISTS forked_from_id bigint;
+CREATE INDEX IF NOT EXISTS idx_palettes_published_at ON palettes (published_at);
+CREATE INDEX IF NOT EXISTS idx_palettes_like_count ON palettes (like_count);
+CREATE INDEX IF NOT EXISTS idx_palettes_forked_from_id ON palettes (forked_from_id);
+
+CREATE TABLE IF NOT EXISTS palette_likes (
+    user_id bigint NOT NULL,
+    palette_id bigint NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (user_id, palette_id)
+);
+CREATE INDEX IF NOT EXISTS idx_palette_likes_palette_id ON palette_likes (palette_id);
@@ -0,0 +1,3 @@
+ALTER TABLE workspaces DROP COLUMN IF EXISTS image_type;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS image_hash;
+ALTER TABLE workspaces ALTER COLUMN image_data DROP DEFAULT;
@@ -0,0 +1,6 @@
+-- Images move to the blob store; image_data only keeps rows that have not
+-- been migrated yet.
+ALTER TABLE workspaces ALTER COLUMN image_data SET DEFAULT '';
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS image_hash varchar(64) NOT NULL DEFAULT '';
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS image_type varchar(100) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_workspaces_image_hash ON workspaces (image_hash);
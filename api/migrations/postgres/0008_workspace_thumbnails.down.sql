@@ -0,0 +1 @@
+ALTER TABLE workspaces DROP COLUMN IF EXISTS thumbnail_hash;
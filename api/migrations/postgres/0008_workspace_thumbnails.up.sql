@@ -0,0 +1 @@
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS thumbnail_hash varchar(64) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE workspaces DROP COLUMN IF EXISTS share_views;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS share_password_hash;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS share_expires_at;
@@ -0,0 +1,4 @@
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS share_expires_at timestamptz;
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS share_password_hash varchar(255) NOT NULL DEFAULT '';
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS share_views bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_workspaces_share_expires_at ON workspaces (share_expires_at);
@@ -0,0 +1,2 @@
+ALTER TABLE workspaces DROP COLUMN IF EXISTS fork_count;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS forked_from_id;
@@ -0,0 +1,3 @@
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS forked_from_id bigint;
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS fork_count bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_workspaces_forked_from_id ON workspaces (forked_from_id);
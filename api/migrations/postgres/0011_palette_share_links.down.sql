@@ -0,0 +1 @@
+ALTER TABLE palettes DROP COLUMN IF EXISTS share_token;
@@ -0,0 +1,2 @@
+ALTER TABLE palettes ADD COLUMN IF NOT EXISTS share_token varchar(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_palettes_share_token ON palettes (share_token);
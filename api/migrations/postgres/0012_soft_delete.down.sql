@@ -0,0 +1,2 @@
+ALTER TABLE workspaces DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE palettes DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,4 @@
+ALTER TABLE palettes ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_palettes_deleted_at ON palettes (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_workspaces_deleted_at ON workspaces (deleted_at);
@@ -0,0 +1 @@
+ALTER TABLE palettes ADD COLUMN IF NOT EXISTS moderated_at timestamptz;
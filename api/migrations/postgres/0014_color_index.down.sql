@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS ciede2000(double precision, double precision, double precision, double precision, double precision, double precision);
+DROP TABLE IF EXISTS workspace_colors;
+DROP TABLE IF EXISTS palette_colors;
@@ -0,0 +1,115 @@
+-- The CIELAB value of every palette and workspace color, so Delta E filters
+-- run in SQL instead of decoding json_data row by row. ciede2000 mirrors
+-- deltaE2000 in color_spaces.go.
+
+CREATE TABLE IF NOT EXISTS palette_colors (
+    palette_id bigint NOT NULL,
+    position integer NOT NULL,
+    l double precision NOT NULL,
+    a double precision NOT NULL,
+    b double precision NOT NULL,
+    PRIMARY KEY (palette_id, position),
+    CONSTRAINT fk_palette_colors_palette FOREIGN KEY (palette_id) REFERENCES palettes (id)
+);
+CREATE INDEX IF NOT EXISTS idx_palette_colors_l ON palette_colors (l);
+
+CREATE TABLE IF NOT EXISTS workspace_colors (
+    workspace_id bigint NOT NULL,
+    position integer NOT NULL,
+    l double precision NOT NULL,
+    a double precision NOT NULL,
+    b double precision NOT NULL,
+    PRIMARY KEY (workspace_id, position),
+    CONSTRAINT fk_workspace_colors_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces (id)
+);
+CREATE INDEX IF NOT EXISTS idx_workspace_colors_l ON workspace_colors (l);
+
+CREATE OR REPLACE FUNCTION ciede2000(
+    l1 double precision, a1 double precision, b1 double precision,
+    l2 double precision, a2 double precision, b2 double precision
+) RETURNS double precision
+LANGUAGE plpgsql IMMUTABLE STRICT PARALLEL SAFE AS $$
+DECLARE
+    pow25_7 constant double precision := 6103515625;
+    c_bar double precision;
+    g double precision;
+    ap1 double precision;
+    ap2 double precision;
+    cp1 double precision;
+    cp2 double precision;
+    hp1 double precision := 0;
+    hp2 double precision := 0;
+    dh double precision := 0;
+    d_h double precision;
+    l_bar double precision;
+    cp_bar double precision;
+    h_bar double precision;
+    t double precision;
+    l50 double precision;
+    sl double precision;
+    sc double precision;
+    sh double precision;
+    rt double precision;
+BEGIN
+    c_bar := (sqrt(a1 * a1 + b1 * b1) + sqrt(a2 * a2 + b2 * b2)) / 2;
+    g := 0.5 * (1 - sqrt(power(c_bar, 7) / (power(c_bar, 7) + pow25_7)));
+
+    ap1 := (1 + g) * a1;
+    ap2 := (1 + g) * a2;
+    cp1 := sqrt(ap1 * ap1 + b1 * b1);
+    cp2 := sqrt(ap2 * ap2 + b2 * b2);
+
+    IF ap1 <> 0 OR b1 <> 0 THEN
+        hp1 := degrees(atan2(b1, ap1));
+        IF hp1 < 0 THEN
+            hp1 := hp1 + 360;
+        END IF;
+    END IF;
+    IF ap2 <> 0 OR b2 <> 0 THEN
+        hp2 := degrees(atan2(b2, ap2));
+        IF hp2 < 0 THEN
+            hp2 := hp2 + 360;
+        END IF;
+    END IF;
+
+    IF cp1 * cp2 <> 0 THEN
+        dh := hp2 - hp1;
+        IF dh > 180 THEN
+            dh := dh - 360;
+        ELSIF dh < -180 THEN
+            dh := dh + 360;
+        END IF;
+    END IF;
+    d_h := 2 * sqrt(cp1 * cp2) * sin(radians(dh / 2));
+
+    l_bar := (l1 + l2) / 2;
+    cp_bar := (cp1 + cp2) / 2;
+
+    h_bar := hp1 + hp2;
+    IF cp1 * cp2 <> 0 THEN
+        IF abs(hp1 - hp2) > 180 THEN
+            IF h_bar < 360 THEN
+                h_bar := h_bar + 360;
+            ELSE
+                h_bar := h_bar - 360;
+            END IF;
+        END IF;
+        h_bar := h_bar / 2;
+    END IF;
+
+    t := 1 - 0.17 * cos(radians(h_bar - 30))
+        + 0.24 * cos(radians(2 * h_bar))
+        + 0.32 * cos(radians(3 * h_bar + 6))
+        - 0.20 * cos(radians(4 * h_bar - 63));
+
+    l50 := (l_bar - 50) * (l_bar - 50);
+    sl := 1 + 0.015 * l50 / sqrt(20 + l50);
+    sc := 1 + 0.045 * cp_bar;
+    sh := 1 + 0.015 * cp_bar * t;
+    rt := -sin(radians(60 * exp(-power((h_bar - 275) / 25, 2))))
+        * 2 * sqrt(power(cp_bar, 7) / (power(cp_bar, 7) + pow25_7));
+
+    RETURN sqrt(power((l2 - l1) / sl, 2) + power((cp2 - cp1) / sc, 2) + power(d_h / sh, 2)
+        + rt * ((cp2 - cp1) / sc) * (d_h / sh));
+END;
+$$;
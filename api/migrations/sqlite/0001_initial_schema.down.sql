@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS workspaces;
+DROP TABLE IF EXISTS palettes;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,40 @@
+-- Same baseline as the Postgres migrations, so both dialects move through
+-- the same versions. JSON columns are stored as text and queried with the
+-- JSON1 functions; timestamps are declared datetime so the driver scans them
+-- back into time.Time.
+
+CREATE TABLE IF NOT EXISTS users (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    name varchar(255) NOT NULL,
+    email varchar(255) NOT NULL,
+    password_hash varchar(255) NOT NULL,
+    created_at datetime,
+    updated_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS palettes (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer,
+    name varchar(255) NOT NULL,
+    json_data text NOT NULL,
+    is_system boolean DEFAULT false,
+    created_at datetime,
+    updated_at datetime,
+    CONSTRAINT fk_users_palettes FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_palettes_user_id ON palettes (user_id);
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer,
+    name varchar(255) NOT NULL,
+    json_data text NOT NULL,
+    image_data text NOT NULL,
+    share_token varchar(64),
+    created_at datetime,
+    updated_at datetime,
+    CONSTRAINT fk_workspaces_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_workspaces_user_id ON workspaces (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspaces_share_token ON workspaces (share_token);
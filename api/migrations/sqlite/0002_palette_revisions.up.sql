@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS palette_revisions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    palette_id integer NOT NULL,
+    revision integer NOT NULL,
+    name varchar(255) NOT NULL,
+    json_data text NOT NULL,
+    created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_palette_revision ON palette_revisions (palette_id, revision);
+CREATE INDEX IF NOT EXISTS idx_palette_revisions_created_at ON palette_revisions (created_at);
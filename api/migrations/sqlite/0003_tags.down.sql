@@ -0,0 +1,2 @@
+ALTER TABLE workspaces DROP COLUMN tags;
+ALTER TABLE palettes DROP COLUMN tags;
@@ -0,0 +1,2 @@
+ALTER TABLE palettes ADD COLUMN tags text NOT NULL DEFAULT '[]';
+ALTER TABLE workspaces ADD COLUMN tags text NOT NULL DEFAULT '[]';
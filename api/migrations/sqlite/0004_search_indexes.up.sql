@@ -0,0 +1,3 @@
+-- The Postgres GIN indexes have no SQLite equivalent; filters scan the JSON
+-- with json_each instead. Kept so both dialects share version numbers.
+SELECT 1;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS palette_likes;
+DROP INDEX IF EXISTS idx_palettes_forked_from_id;
+DROP INDEX IF EXISTS idx_palettes_like_count;
+DROP INDEX IF EXISTS idx_palettes_published_at;
+ALTER TABLE palettes DROP COLUMN forked_from_id;
+ALTER TABLE palettes DROP COLUMN like_count;
+ALTER TABLE palettes DROP COLUMN published_at;
+ALTER TABLE users DROP COLUMN is_admin;
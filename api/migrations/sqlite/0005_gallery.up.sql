@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN is_admin boolean DEFAULT false;
+
+ALTER TABLE palettes ADD COLUMN published_at datetime;
+ALTER TABLE palettes ADD COLUMN like_count integer NOT NULL DEFAULT 0;
+ALTER TABLE palettes ADD COLUMN forked_from_id integer;
+CREATE INDEX IF NOT EXISTS idx_palettes_published_at ON palettes (published_at);
+CREATE INDEX IF NOT EXISTS idx_palettes_like_count ON palettes (like_count);
+CREATE INDEX IF NOT EXISTS idx_palettes_forked_from_id ON palettes (forked_from_id);
+
+CREATE TABLE IF NOT EXISTS palette_likes (
+    user_id integer NOT NULL,
+    palette_id integer NOT NULL,
+    created_at datetime,
+    PRIMARY KEY (user_id, palette_id)
+);
+CREATE INDEX IF NOT EXISTS idx_palette_likes_palette_id ON palette_likes (palette_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS collection_workspaces;
+DROP TABLE IF EXISTS collection_palettes;
+DROP TABLE IF EXISTS collections;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS collections (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer NOT NULL,
+    name varchar(255) NOT NULL,
+    created_at datetime,
+    updated_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collection_user_name ON collections (user_id, name);
+
+CREATE TABLE IF NOT EXISTS collection_palettes (
+    collection_id integer NOT NULL,
+    palette_id integer NOT NULL,
+    PRIMARY KEY (collection_id, palette_id),
+    CONSTRAINT fk_collection_palettes_collection FOREIGN KEY (collection_id) REFERENCES collections (id),
+    CONSTRAINT fk_collection_palettes_palette FOREIGN KEY (palette_id) REFERENCES palettes (id)
+);
+
+CREATE TABLE IF NOT EXISTS collection_workspaces (
+    collection_id integer NOT NULL,
+    workspace_id integer NOT NULL,
+    PRIMARY KEY (collection_id, workspace_id),
+    CONSTRAINT fk_collection_workspaces_collection FOREIGN KEY (collection_id) REFERENCES collections (id),
+    CONSTRAINT fk_collection_workspaces_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces (id)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_workspaces_image_hash;
+ALTER TABLE workspaces DROP COLUMN image_type;
+ALTER TABLE workspaces DROP COLUMN image_hash;
@@ -0,0 +1,5 @@
+-- SQLite cannot change a column default in place. image_data keeps no
+-- default; GORM always writes the column, so inserts still get ''.
+ALTER TABLE workspaces ADD COLUMN image_hash varchar(64) NOT NULL DEFAULT '';
+ALTER TABLE workspaces ADD COLUMN image_type varchar(100) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_workspaces_image_hash ON workspaces (image_hash);
@@ -0,0 +1 @@
+ALTER TABLE workspaces ADD COLUMN thumbnail_hash varchar(64) NOT NULL DEFAULT '';
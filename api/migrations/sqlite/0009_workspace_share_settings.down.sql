@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_workspaces_share_expires_at;
+ALTER TABLE workspaces DROP COLUMN share_views;
+ALTER TABLE workspaces DROP COLUMN share_password_hash;
+ALTER TABLE workspaces DROP COLUMN share_expires_at;
@@ -0,0 +1,4 @@
+ALTER TABLE workspaces ADD COLUMN share_expires_at datetime;
+ALTER TABLE workspaces ADD COLUMN share_password_hash varchar(255) NOT NULL DEFAULT '';
+ALTER TABLE workspaces ADD COLUMN share_views integer NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_workspaces_share_expires_at ON workspaces (share_expires_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_workspaces_forked_from_id;
+ALTER TABLE workspaces DROP COLUMN fork_count;
+ALTER TABLE workspaces DROP COLUMN forked_from_id;
@@ -0,0 +1,3 @@
+ALTER TABLE workspaces ADD COLUMN forked_from_id integer;
+ALTER TABLE workspaces ADD COLUMN fork_count integer NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_workspaces_forked_from_id ON workspaces (forked_from_id);
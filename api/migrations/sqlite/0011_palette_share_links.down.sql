@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_palettes_share_token;
+ALTER TABLE palettes DROP COLUMN share_token;
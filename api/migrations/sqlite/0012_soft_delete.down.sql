@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_workspaces_deleted_at;
+DROP INDEX IF EXISTS idx_palettes_deleted_at;
+ALTER TABLE workspaces DROP COLUMN deleted_at;
+ALTER TABLE palettes DROP COLUMN deleted_at;
@@ -0,0 +1,4 @@
+ALTER TABLE palettes ADD COLUMN deleted_at datetime;
+ALTER TABLE workspaces ADD COLUMN deleted_at datetime;
+CREATE INDEX IF NOT EXISTS idx_palettes_deleted_at ON palettes (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_workspaces_deleted_at ON workspaces (deleted_at);
@@ -0,0 +1 @@
+ALTER TABLE palettes DROP COLUMN moderated_at;
@@ -0,0 +1 @@
+ALTER TABLE palettes ADD COLUMN moderated_at datetime;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS workspace_colors;
+DROP TABLE IF EXISTS palette_colors;
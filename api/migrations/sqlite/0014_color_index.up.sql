@@ -0,0 +1,25 @@
+-- The CIELAB value of every palette and workspace color, so Delta E filters
+-- run in SQL instead of decoding json_data row by row. ciede2000 is
+-- registered with the driver in db.go.
+
+CREATE TABLE IF NOT EXISTS palette_colors (
+    palette_id integer NOT NULL,
+    position integer NOT NULL,
+    l real NOT NULL,
+    a real NOT NULL,
+    b real NOT NULL,
+    PRIMARY KEY (palette_id, position),
+    CONSTRAINT fk_palette_colors_palette FOREIGN KEY (palette_id) REFERENCES palettes (id)
+);
+CREATE INDEX IF NOT EXISTS idx_palette_colors_l ON palette_colors (l);
+
+CREATE TABLE IF NOT EXISTS workspace_colors (
+    workspace_id integer NOT NULL,
+    position integer NOT NULL,
+    l real NOT NULL,
+    a real NOT NULL,
+    b real NOT NULL,
+    PRIMARY KEY (workspace_id, position),
+    CONSTRAINT fk_workspace_colors_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces (id)
+);
+CREATE INDEX IF NOT EXISTS idx_workspace_colors_l ON workspace_colors (l);
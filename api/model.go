@@ -1,36 +1,83 @@
 package main
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	ID           uint      `json:"id" gorm:"primaryKey"`
 	Name         string    `json:"name" gorm:"size:255;not null"`
 	Email        string    `json:"email" gorm:"size:255;uniqueIndex;not null"`
 	PasswordHash string    `json:"-" gorm:"size:255;not null"`
+	IsAdmin      bool      `json:"isAdmin" gorm:"default:false"`
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time `json:"updatedAt"`
 	Palettes     []Palette `json:"palettes" gorm:"foreignKey:UserID"`
 }
 
 type Palette struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	UserID       *uint          `json:"userId" gorm:"index"`
+	User         *User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Name         string         `json:"name" gorm:"size:255;not null"`
+	JsonData     string         `json:"jsonData" gorm:"not null"`
+	Tags         string         `json:"tags" gorm:"not null;default:'[]'"`
+	IsSystem     bool           `json:"isSystem" gorm:"default:false"`
+	PublishedAt  *time.Time     `json:"publishedAt" gorm:"index"`
+	ModeratedAt  *time.Time     `json:"moderatedAt"`
+	LikeCount    int            `json:"likeCount" gorm:"not null;default:0;index"`
+	ForkedFromID *uint          `json:"forkedFromId" gorm:"index"`
+	ShareToken   *string        `json:"shareToken" gorm:"size:64;uniqueIndex"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	DeletedAt    gorm.DeletedAt `json:"deletedAt" gorm:"index"`
+}
+
+type PaletteLike struct {
+	UserID    uint      `json:"userId" gorm:"primaryKey"`
+	PaletteID uint      `json:"paletteId" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type PaletteRevision struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
-	UserID    *uint     `json:"userId" gorm:"index"`
-	User      *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	PaletteID uint      `json:"paletteId" gorm:"not null;uniqueIndex:idx_palette_revision"`
+	Revision  int       `json:"revision" gorm:"not null;uniqueIndex:idx_palette_revision"`
 	Name      string    `json:"name" gorm:"size:255;not null"`
-	JsonData  string    `json:"jsonData" gorm:"type:jsonb;not null"`
-	IsSystem  bool      `json:"isSystem" gorm:"default:false"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	JsonData  string    `json:"jsonData" gorm:"not null"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
 }
 
 type Workspace struct {
-	ID         uint      `json:"id" gorm:"primaryKey"`
-	UserID     *uint     `json:"userId" gorm:"index"`
-	User       *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	Name       string    `json:"name" gorm:"size:255;not null"`
-	JsonData   string    `json:"jsonData" gorm:"type:jsonb;not null"`
-	ImageData  string    `json:"imageData" gorm:"type:text;not null"`
-	ShareToken *string   `json:"shareToken" gorm:"size:64;uniqueIndex"`
-	CreatedAt  time.Time `json:"createdAt"`
-	UpdatedAt  time.Time `json:"updatedAt"`
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	UserID            *uint          `json:"userId" gorm:"index"`
+	User              *User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Name              string         `json:"name" gorm:"size:255;not null"`
+	JsonData          string         `json:"jsonData" gorm:"not null"`
+	ImageData         string         `json:"imageData" gorm:"type:text;not null;default:''"`
+	ImageHash         string         `json:"imageHash" gorm:"size:64;not null;default:'';index"`
+	ImageType         string         `json:"imageType" gorm:"size:100;not null;default:''"`
+	ThumbnailHash     string         `json:"thumbnailHash" gorm:"size:64;not null;default:''"`
+	Tags              string         `json:"tags" gorm:"not null;default:'[]'"`
+	ShareToken        *string        `json:"shareToken" gorm:"size:64;uniqueIndex"`
+	ShareExpiresAt    *time.Time     `json:"shareExpiresAt" gorm:"index"`
+	SharePasswordHash string         `json:"-" gorm:"size:255;not null;default:''"`
+	ShareViews        int            `json:"shareViews" gorm:"not null;default:0"`
+	ForkedFromID      *uint          `json:"forkedFromId" gorm:"index"`
+	ForkCount         int            `json:"forkCount" gorm:"not null;default:0"`
+	CreatedAt         time.Time      `json:"createdAt"`
+	UpdatedAt         time.Time      `json:"updatedAt"`
+	DeletedAt         gorm.DeletedAt `json:"deletedAt" gorm:"index"`
+}
+
+type Collection struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	UserID     uint        `json:"userId" gorm:"not null;uniqueIndex:idx_collection_user_name"`
+	Name       string      `json:"name" gorm:"size:255;not null;uniqueIndex:idx_collection_user_name"`
+	Palettes   []Palette   `json:"palettes,omitempty" gorm:"many2many:collection_palettes"`
+	Workspaces []Workspace `json:"workspaces,omitempty" gorm:"many2many:collection_workspaces"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	UpdatedAt  time.Time   `json:"updatedAt"`
 }
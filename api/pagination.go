@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+type PageParams struct {
+	Limit  int
+	Cursor *pageCursor
+}
+
+// pageCursor identifies the last item of the previous page by its sort key
+// and id; the id breaks ties between rows sharing a key.
+type pageCursor struct {
+	Key int64
+	ID  uint
+}
+
+// pageSort is the column a list is ordered by (descending) and how a cursor
+// key is turned back into a value comparable with it.
+type pageSort struct {
+	column string
+	value  func(key int64) any
+}
+
+var (
+	sortByCreated   = pageSort{"created_at", timeKeyValue}
+	sortByPublished = pageSort{"published_at", timeKeyValue}
+	sortByLikes     = pageSort{"like_count", func(key int64) any { return key }}
+)
+
+// Time keys are stored in microseconds, the precision Postgres keeps.
+func timeKey(t time.Time) int64 {
+	return t.UnixMicro()
+}
+
+func timeKeyValue(key int64) any {
+	return time.UnixMicro(key).UTC()
+}
+
+func parsePageParams(c *gin.Context) (PageParams, error) {
+	page := PageParams{Limit: defaultPageLimit}
+
+	if s := c.Query("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return page, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		page.Limit = limit
+	}
+
+	if s := c.Query("cursor"); s != "" {
+		cursor, err := decodeCursor(s)
+		if err != nil {
+			return page, err
+		}
+		page.Cursor = cursor
+	}
+
+	return page, nil
+}
+
+// Cursors are opaque to clients but are simply the sort key and id of the
+// last item seen.
+func encodeCursor(key int64, id uint) string {
+	raw := fmt.Sprintf("%d:%d", key, id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	key, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	parsedKey, err := strconv.ParseInt(key, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	parsedID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &pageCursor{Key: parsedKey, ID: uint(parsedID)}, nil
+}
+
+func applyCursor(query *gorm.DB, sort pageSort, cursor *pageCursor) *gorm.DB {
+	if cursor != nil {
+		value := sort.value(cursor.Key)
+		query = query.Where(fmt.Sprintf("%[1]s < ? OR (%[1]s = ? AND id < ?)", sort.column),
+			value, value, cursor.ID)
+	}
+	return query.Order(sort.column + " DESC, id DESC")
+}
+
+// fetchPage reads one page newest first from the cursor. It asks for one row
+// more than the limit to learn whether another page follows.
+func fetchPage[T any](query *gorm.DB, page PageParams, sort pageSort, key func(T) (int64, uint)) ([]T, string, error) {
+	var rows []T
+	if err := applyCursor(query, sort, page.Cursor).Limit(page.Limit + 1).Find(&rows).Error; err != nil {
+		return nil, "", err
+	}
+
+	if len(rows) <= page.Limit {
+		return rows, "", nil
+	}
+
+	rows = rows[:page.Limit]
+	lastKey, lastID := key(rows[len(rows)-1])
+	return rows, encodeCursor(lastKey, lastID), nil
+}
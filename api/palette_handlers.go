@@ -2,29 +2,63 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+var (
+	errPaletteNotFound = errors.New("palette not found or unauthorized")
+	errSystemPalette   = errors.New("cannot modify system palettes")
+	errPaletteConflict = errors.New("palette was modified by another request")
+	errColorIndex      = errors.New("color index out of range")
+)
+
 type PaletteData struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Palette   []Color   `json:"palette"`
-	CreatedAt time.Time `json:"createdAt"`
-	IsSystem  bool      `json:"isSystem"`
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Palette      []Color       `json:"palette"`
+	Tags         []string      `json:"tags"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+	IsSystem     bool          `json:"isSystem"`
+	PublishedAt  *time.Time    `json:"publishedAt,omitempty"`
+	ModeratedAt  *time.Time    `json:"moderatedAt,omitempty"`
+	LikeCount    int           `json:"likeCount"`
+	ForkedFromID *string       `json:"forkedFromId,omitempty"`
+	Share        *PaletteShare `json:"share,omitempty"`
 }
 
 type SavePaletteRequest struct {
-	Name    string  `json:"name" binding:"required"`
-	Palette []Color `json:"palette" binding:"required"`
+	Name    string   `json:"name" binding:"required"`
+	Palette []Color  `json:"palette" binding:"required"`
+	Tags    []string `json:"tags"`
 }
 
 type GetPalettesResponse struct {
-	Palettes []PaletteData `json:"palettes"`
+	Palettes   []PaletteData `json:"palettes"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+type UpdatePaletteRequest struct {
+	Name      *string    `json:"name"`
+	Palette   []Color    `json:"palette"`
+	Tags      []string   `json:"tags"`
+	UpdatedAt *time.Time `json:"updatedAt"`
+}
+
+type NameColorsRequest struct {
+	Palette []Color `json:"palette" binding:"required"`
+}
+
+type RenamePaletteColorRequest struct {
+	Name string `json:"name"`
 }
 
 func isAuthenticated(c *gin.Context) (bool, uint) {
@@ -46,13 +80,19 @@ func isAuthenticated(c *gin.Context) (bool, uint) {
 	return true, claims.UserID
 }
 
-func savePaletteHandler(c *gin.Context) {
+func (s *Server) savePaletteHandler(c *gin.Context) {
 	var req SavePaletteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	authenticated, userID := isAuthenticated(c)
 
 	if !authenticated {
@@ -60,7 +100,7 @@ func savePaletteHandler(c *gin.Context) {
 		return
 	}
 
-	err := saveUserPalette(userID, req.Name, req.Palette)
+	_, err = s.saveUserPalette(userID, req.Name, req.Palette, tags)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save palette"})
 		return
@@ -72,7 +112,7 @@ func savePaletteHandler(c *gin.Context) {
 	})
 }
 
-func getPalettesHandler(c *gin.Context) {
+func (s *Server) getPalettesHandler(c *gin.Context) {
 	authenticated, userID := isAuthenticated(c)
 
 	if !authenticated {
@@ -80,16 +120,28 @@ func getPalettesHandler(c *gin.Context) {
 		return
 	}
 
-	palettes, err := getUserPalettes(userID)
+	filter, err := parseListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := parsePageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	palettes, nextCursor, err := s.getUserPalettes(userID, filter, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch palettes"})
 		return
 	}
 
-	c.JSON(http.StatusOK, GetPalettesResponse{Palettes: palettes})
+	c.JSON(http.StatusOK, GetPalettesResponse{Palettes: palettes, NextCursor: nextCursor})
 }
 
-func deletePaletteHandler(c *gin.Context) {
+func (s *Server) deletePaletteHandler(c *gin.Context) {
 	paletteID := c.Param("id")
 	if paletteID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Palette ID is required"})
@@ -103,84 +155,377 @@ func deletePaletteHandler(c *gin.Context) {
 		return
 	}
 
-	err := deleteUserPalette(userID, paletteID)
+	err := s.deleteUserPalette(userID, paletteID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, errPaletteNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errSystemPalette):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Palette deleted successfully"})
 }
 
-func saveUserPalette(userID uint, name string, palette []Color) error {
-	if DB == nil {
-		return fmt.Errorf("database not available")
+func (s *Server) updatePaletteHandler(c *gin.Context) {
+	paletteID := c.Param("id")
+	if paletteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Palette ID is required"})
+		return
+	}
+
+	var req UpdatePaletteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Request.Method == http.MethodPut && (req.Name == nil || req.Palette == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "PUT requires both name and palette"})
+		return
+	}
+	if req.Name == nil && req.Palette == nil && req.Tags == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+		return
+	}
+	if req.Palette != nil {
+		if _, err := parsePaletteRGBAs(req.Palette); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Tags != nil {
+		tags, err := normalizeTags(req.Tags)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Tags = tags
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to update palettes"})
+		return
+	}
+
+	palette, err := s.updateUserPalette(userID, paletteID, req, c.GetHeader("If-Match"))
+	if err != nil {
+		switch {
+		case errors.Is(err, errPaletteNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errSystemPalette):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, errPaletteConflict) && c.GetHeader("If-Match") != "":
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		case errors.Is(err, errPaletteConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update palette"})
+		}
+		return
+	}
+
+	c.Header("ETag", versionETag(palette.UpdatedAt))
+	c.JSON(http.StatusOK, palette)
+}
+
+func nameColorsHandler(c *gin.Context) {
+	var req NameColorsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !checkPaletteSize(c, req.Palette) {
+		return
+	}
+	if _, err := parsePaletteRGBAs(req.Palette); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"palette": withColorNames(req.Palette)})
+}
+
+func (s *Server) renamePaletteColorHandler(c *gin.Context) {
+	paletteID := c.Param("id")
+	index, err := strconv.Atoi(c.Param("index"))
+	if paletteID == "" || err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Palette ID and color index are required"})
+		return
+	}
+
+	var req RenamePaletteColorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to rename palette colors"})
+		return
+	}
+
+	palette, err := s.renameUserPaletteColor(userID, paletteID, index, strings.TrimSpace(req.Name))
+	if err != nil {
+		switch {
+		case errors.Is(err, errPaletteNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errSystemPalette):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, errColorIndex):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errPaletteConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Printf("Failed to rename color %d of palette %s: %v", index, paletteID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename palette color"})
+		}
+		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{"palette": withColorNames(palette)})
+}
+
+func (s *Server) saveUserPalette(userID uint, name string, palette []Color, tags []string) (*Palette, error) {
 	paletteJSON, err := json.Marshal(palette)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	dbPalette := Palette{
 		UserID:   &userID,
 		JsonData: string(paletteJSON),
+		Tags:     encodeTags(tags),
 		Name:     name,
 	}
 
-	return DB.Create(&dbPalette).Error
+	if err := s.Palettes.Create(&dbPalette); err != nil {
+		return nil, err
+	}
+	return &dbPalette, nil
 }
 
-func getUserPalettes(userID uint) ([]PaletteData, error) {
-	if DB == nil {
-		return nil, fmt.Errorf("database not available")
+func (s *Server) getUserPalettes(userID uint, filter ListFilter, page PageParams) ([]PaletteData, string, error) {
+	dbPalettes, nextCursor, err := s.Palettes.List(userID, filter, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	palettes := make([]PaletteData, 0, len(dbPalettes))
+	for _, dbPalette := range dbPalettes {
+		var colors []Color
+		json.Unmarshal([]byte(dbPalette.JsonData), &colors)
+		palettes = append(palettes, toPaletteData(dbPalette, colors))
 	}
 
-	var dbPalettes []Palette
-	err := DB.Where("user_id = ?", userID).
-		Order("created_at DESC").
-		Find(&dbPalettes).Error
+	return palettes, nextCursor, nil
+}
+
+func toPaletteData(dbPalette Palette, colors []Color) PaletteData {
+	data := PaletteData{
+		ID:          fmt.Sprintf("%d", dbPalette.ID),
+		Name:        dbPalette.Name,
+		Palette:     withColorNames(colors),
+		Tags:        decodeTags(dbPalette.Tags),
+		CreatedAt:   dbPalette.CreatedAt,
+		UpdatedAt:   dbPalette.UpdatedAt,
+		IsSystem:    dbPalette.IsSystem,
+		PublishedAt: dbPalette.PublishedAt,
+		ModeratedAt: dbPalette.ModeratedAt,
+		LikeCount:   dbPalette.LikeCount,
+		Share:       toPaletteShare(dbPalette),
+	}
+	if dbPalette.ForkedFromID != nil {
+		forkedFrom := fmt.Sprintf("%d", *dbPalette.ForkedFromID)
+		data.ForkedFromID = &forkedFrom
+	}
+	return data
+}
+
+// versionETag derives an ETag from updated_at, which every update guards on
+// for optimistic concurrency.
+func versionETag(updatedAt time.Time) string {
+	return fmt.Sprintf("\"%d\"", updatedAt.UnixMicro())
+}
+
+func (s *Server) getUserPaletteColors(userID uint, paletteID string) ([]Color, error) {
+	palette, err := s.Palettes.Get(userID, paletteID)
 	if err != nil {
 		return nil, err
 	}
 
-	palettes := make([]PaletteData, len(dbPalettes))
-	for i, dbPalette := range dbPalettes {
-		var colors []Color
-		if err := json.Unmarshal([]byte(dbPalette.JsonData), &colors); err != nil {
-			continue
+	var colors []Color
+	if err := json.Unmarshal([]byte(palette.JsonData), &colors); err != nil {
+		return nil, fmt.Errorf("failed to parse palette data")
+	}
+
+	return colors, nil
+}
+
+// maxPaletteColors bounds the palettes the analysis endpoints accept. Most of
+// them compare every pair of colors, and compare and reduce are cubic.
+const maxPaletteColors = 64
+
+// checkPaletteSize responds with 400 and returns false for palettes over
+// maxPaletteColors.
+func checkPaletteSize(c *gin.Context, palette []Color) bool {
+	if len(palette) > maxPaletteColors {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Palette must have at most %d colors", maxPaletteColors)})
+		return false
+	}
+	return true
+}
+
+// resolvePaletteColors returns the inline palette or the caller's saved one,
+// having responded with an error instead when neither is usable.
+func (s *Server) resolvePaletteColors(c *gin.Context, palette []Color, paletteID string) ([]Color, bool) {
+	if paletteID == "" {
+		if len(palette) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Either palette or paletteId is required"})
+			return nil, false
 		}
+		return palette, checkPaletteSize(c, palette)
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to use saved palettes"})
+		return nil, false
+	}
 
-		palettes[i] = PaletteData{
-			ID:        fmt.Sprintf("%d", dbPalette.ID),
-			Name:      dbPalette.Name,
-			Palette:   colors,
-			CreatedAt: dbPalette.CreatedAt,
-			IsSystem:  dbPalette.IsSystem,
+	colors, err := s.getUserPaletteColors(userID, paletteID)
+	if err != nil {
+		if errors.Is(err, errPaletteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return nil, false
 		}
+		log.Printf("Failed to load palette %s: %v", paletteID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load palette"})
+		return nil, false
 	}
 
-	return palettes, nil
+	return colors, checkPaletteSize(c, colors)
 }
 
-func deleteUserPalette(userID uint, paletteID string) error {
-	if DB == nil {
-		return fmt.Errorf("database not available")
+func (s *Server) renameUserPaletteColor(userID uint, paletteID string, index int, name string) ([]Color, error) {
+	palette, err := s.Palettes.Get(userID, paletteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if palette.IsSystem {
+		return nil, errSystemPalette
 	}
 
-	var palette Palette
-	if err := DB.Where("id = ? AND user_id = ?", paletteID, userID).First(&palette).Error; err != nil {
-		return fmt.Errorf("palette not found or unauthorized")
+	var colors []Color
+	if err := json.Unmarshal([]byte(palette.JsonData), &colors); err != nil {
+		return nil, fmt.Errorf("failed to parse palette data")
+	}
+
+	if index < 0 || index >= len(colors) {
+		return nil, errColorIndex
+	}
+
+	colors[index].Name = name
+
+	paletteJSON, err := json.Marshal(colors)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Palettes.Update(palette, map[string]any{"json_data": string(paletteJSON)}); err != nil {
+		return nil, err
+	}
+
+	return colors, nil
+}
+
+// Concurrency is optimistic: callers pass the updatedAt they last saw, either
+// in the body or as an If-Match ETag, and the row is only written if it still
+// carries that timestamp.
+func (s *Server) updateUserPalette(userID uint, paletteID string, req UpdatePaletteRequest, ifMatch string) (*PaletteData, error) {
+	palette, err := s.Palettes.Get(userID, paletteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if palette.IsSystem {
+		return nil, errSystemPalette
+	}
+
+	if ifMatch != "" && ifMatch != "*" && ifMatch != versionETag(palette.UpdatedAt) {
+		return nil, errPaletteConflict
+	}
+	if req.UpdatedAt != nil && req.UpdatedAt.UnixMicro() != palette.UpdatedAt.UnixMicro() {
+		return nil, errPaletteConflict
+	}
+
+	updates := map[string]any{}
+	if req.Name != nil {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Palette != nil {
+		paletteJSON, err := json.Marshal(req.Palette)
+		if err != nil {
+			return nil, err
+		}
+		updates["json_data"] = string(paletteJSON)
+	}
+	if req.Tags != nil {
+		updates["tags"] = encodeTags(req.Tags)
+	}
+
+	if err := s.Palettes.Update(palette, updates); err != nil {
+		return nil, err
+	}
+
+	var colors []Color
+	if err := json.Unmarshal([]byte(palette.JsonData), &colors); err != nil {
+		return nil, fmt.Errorf("failed to parse palette data")
+	}
+
+	data := toPaletteData(*palette, colors)
+	return &data, nil
+}
+
+func (s *Server) updateUserPaletteColors(userID uint, paletteID string, colors []Color) error {
+	palette, err := s.Palettes.Get(userID, paletteID)
+	if err != nil {
+		return err
 	}
 
 	if palette.IsSystem {
-		return fmt.Errorf("cannot delete system palettes")
+		return errSystemPalette
 	}
 
-	result := DB.Delete(&palette)
-	if result.Error != nil {
-		return result.Error
+	paletteJSON, err := json.Marshal(colors)
+	if err != nil {
+		return err
+	}
+
+	return s.Palettes.Update(palette, map[string]any{"json_data": string(paletteJSON)})
+}
+
+func (s *Server) deleteUserPalette(userID uint, paletteID string) error {
+	palette, err := s.Palettes.Get(userID, paletteID)
+	if err != nil {
+		return err
+	}
+
+	if palette.IsSystem {
+		return errSystemPalette
 	}
 
-	return nil
+	return s.Palettes.Delete(palette)
 }
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errSharedPaletteNotFound = errors.New("shared palette not found")
+
+const (
+	defaultSwatchSize = 40
+	minSwatchSize     = 8
+	maxSwatchSize     = 256
+)
+
+// PaletteShare describes an active palette share link to the palette owner.
+// Palette links are meant for embedding, so they point straight at the API.
+type PaletteShare struct {
+	ShareToken string `json:"shareToken"`
+	JSONURL    string `json:"jsonUrl"`
+	SVGURL     string `json:"svgUrl"`
+}
+
+type SharedPaletteData struct {
+	Name      string    `json:"name"`
+	Palette   []Color   `json:"palette"`
+	Tags      []string  `json:"tags"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// publicAPIURL is the address embeds reach the API at.
+func publicAPIURL() string {
+	return strings.TrimSuffix(getEnv("PUBLIC_API_URL", "http://localhost:8088"), "/")
+}
+
+func toPaletteShare(palette Palette) *PaletteShare {
+	if palette.ShareToken == nil {
+		return nil
+	}
+	base := publicAPIURL() + "/shared/palettes/" + *palette.ShareToken
+	return &PaletteShare{
+		ShareToken: *palette.ShareToken,
+		JSONURL:    base,
+		SVGURL:     base + "?format=svg",
+	}
+}
+
+func (s *Server) sharePaletteHandler(c *gin.Context) {
+	paletteID := c.Param("id")
+	if paletteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Palette ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to share palettes"})
+		return
+	}
+
+	share, err := s.createPaletteShareToken(userID, paletteID)
+	if err != nil {
+		if errors.Is(err, errPaletteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, share)
+}
+
+func (s *Server) removePaletteShareHandler(c *gin.Context) {
+	paletteID := c.Param("id")
+	if paletteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Palette ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	if err := s.removePaletteShareToken(userID, paletteID); err != nil {
+		if errors.Is(err, errPaletteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share link removed successfully"})
+}
+
+// Supports format=json (default) or format=svg, a strip of square swatches
+// whose edge length is set with size (8-256 pixels).
+func (s *Server) getSharedPaletteHandler(c *gin.Context) {
+	shareToken := c.Param("token")
+	if shareToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Share token is required"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "svg" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or svg"})
+		return
+	}
+
+	size := defaultSwatchSize
+	if raw := c.Query("size"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < minSwatchSize || parsed > maxSwatchSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("size must be between %d and %d", minSwatchSize, maxSwatchSize)})
+			return
+		}
+		size = parsed
+	}
+
+	palette, err := s.getPaletteByShareToken(shareToken)
+	if err != nil {
+		if errors.Is(err, errSharedPaletteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Shared palette not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Embeds are fetched often; a short public cache plus the version ETag
+	// keeps them cheap while edits still show up within minutes.
+	etag := versionETag(palette.UpdatedAt)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=300")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if format == "svg" {
+		c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", renderPaletteSVG(palette.Name, palette.Palette, size))
+		return
+	}
+	c.JSON(http.StatusOK, palette)
+}
+
+// Sharing does not change updated_at, which guards concurrent edits.
+func (s *Server) createPaletteShareToken(userID uint, paletteID string) (*PaletteShare, error) {
+	palette, err := s.Palettes.Get(userID, paletteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if palette.ShareToken == nil {
+		shareToken, err := generateShareToken()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate share token")
+		}
+		if err := s.Palettes.SetShareToken(palette, &shareToken); err != nil {
+			return nil, fmt.Errorf("failed to save share token")
+		}
+	}
+
+	return toPaletteShare(*palette), nil
+}
+
+func (s *Server) removePaletteShareToken(userID uint, paletteID string) error {
+	palette, err := s.Palettes.Get(userID, paletteID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Palettes.SetShareToken(palette, nil); err != nil {
+		return fmt.Errorf("failed to remove share token")
+	}
+
+	return nil
+}
+
+func (s *Server) getPaletteByShareToken(shareToken string) (*SharedPaletteData, error) {
+	palette, err := s.Palettes.GetByShareToken(shareToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var colors []Color
+	if err := json.Unmarshal([]byte(palette.JsonData), &colors); err != nil {
+		return nil, fmt.Errorf("failed to parse palette data")
+	}
+
+	return &SharedPaletteData{
+		Name:      palette.Name,
+		Palette:   withColorNames(colors),
+		Tags:      decodeTags(palette.Tags),
+		UpdatedAt: palette.UpdatedAt,
+	}, nil
+}
+
+// renderPaletteSVG draws the palette as a row of size x size swatches. Fill
+// colors are re-formatted from the parsed value and all text is escaped, so
+// stored names and hex strings cannot inject markup into the embed.
+func renderPaletteSVG(name string, colors []Color, size int) []byte {
+	width := max(len(colors), 1) * size
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" aria-label="%s">`,
+		width, size, width, size, html.EscapeString(name))
+	fmt.Fprintf(&buf, "<title>%s</title>", html.EscapeString(name))
+
+	for i, color := range colors {
+		rgba, err := hexToRGBA(color.Hex)
+		if err != nil {
+			continue
+		}
+		fill := fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+
+		label := fill
+		if color.Name != "" {
+			label = color.Name + " " + fill
+		}
+		fmt.Fprintf(&buf, `<rect x="%d" y="0" width="%d" height="%d" fill="%s"><title>%s</title></rect>`,
+			i*size, size, size, fill, html.EscapeString(label))
+	}
+
+	buf.WriteString("</svg>")
+	return buf.Bytes()
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaletteReduceRequest struct {
+	Palette   []Color   `json:"palette"`
+	PaletteID string    `json:"paletteId"`
+	Count     int       `json:"count"`
+	Threshold float64   `json:"threshold"`
+	Weights   []float64 `json:"weights"`
+	InPlace   bool      `json:"inPlace"`
+}
+
+type PaletteReduceResponse struct {
+	Palette []Color `json:"palette"`
+	Groups  [][]int `json:"groups"`
+	Saved   bool    `json:"saved"`
+}
+
+type colorCluster struct {
+	members []int
+	weight  float64
+	lab     cielab
+	rep     int
+}
+
+func (s *Server) paletteReduceHandler(c *gin.Context) {
+	var req PaletteReduceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Count <= 0 && req.Threshold <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Either count or threshold is required"})
+		return
+	}
+	if req.InPlace && req.PaletteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "inPlace requires paletteId"})
+		return
+	}
+
+	palette, ok := s.resolvePaletteColors(c, req.Palette, req.PaletteID)
+	if !ok {
+		return
+	}
+
+	reduced, groups, err := reducePalette(palette, req.Weights, req.Count, req.Threshold)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.InPlace {
+		_, userID := isAuthenticated(c)
+		if err := s.updateUserPaletteColors(userID, req.PaletteID, reduced); err != nil {
+			switch {
+			case errors.Is(err, errPaletteNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case errors.Is(err, errSystemPalette):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case errors.Is(err, errPaletteConflict):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				log.Printf("Failed to save reduced palette %s: %v", req.PaletteID, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save palette"})
+			}
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, PaletteReduceResponse{
+		Palette: reduced,
+		Groups:  groups,
+		Saved:   req.InPlace,
+	})
+}
+
+// Agglomerative clustering in CIELAB: the two clusters whose weighted
+// centroids are closest by CIEDE2000 merge until both the count and the
+// threshold are satisfied. Each cluster keeps its heaviest original color
+// rather than the blended centroid, so exact brand values survive.
+func reducePalette(palette []Color, weights []float64, count int, threshold float64) ([]Color, [][]int, error) {
+	if len(weights) > 0 && len(weights) != len(palette) {
+		return nil, nil, fmt.Errorf("weights must have one entry per color")
+	}
+
+	rgbas, err := parsePaletteRGBAs(palette)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clusters := make([]*colorCluster, len(rgbas))
+	for i, rgba := range rgbas {
+		weight := 1.0
+		if len(weights) > 0 {
+			weight = weights[i]
+			if weight <= 0 {
+				return nil, nil, fmt.Errorf("weights must be positive")
+			}
+		}
+		clusters[i] = &colorCluster{
+			members: []int{i},
+			weight:  weight,
+			lab:     rgbaToLab(rgba),
+			rep:     i,
+		}
+	}
+
+	for len(clusters) > 1 {
+		bestI, bestJ := -1, -1
+		bestDist := math.MaxFloat64
+		for i := range clusters {
+			for j := i + 1; j < len(clusters); j++ {
+				if d := deltaE2000(clusters[i].lab, clusters[j].lab); d < bestDist {
+					bestI, bestJ, bestDist = i, j, d
+				}
+			}
+		}
+
+		needCount := count > 0 && len(clusters) > count
+		needThreshold := threshold > 0 && bestDist < threshold
+		if !needCount && !needThreshold {
+			break
+		}
+
+		clusters[bestI] = mergeClusters(clusters[bestI], clusters[bestJ], weights)
+		clusters = append(clusters[:bestJ], clusters[bestJ+1:]...)
+	}
+
+	reduced := make([]Color, len(clusters))
+	groups := make([][]int, len(clusters))
+	for i, cluster := range clusters {
+		reduced[i] = palette[cluster.rep]
+		groups[i] = cluster.members
+	}
+
+	return reduced, groups, nil
+}
+
+func mergeClusters(a, b *colorCluster, weights []float64) *colorCluster {
+	total := a.weight + b.weight
+	merged := &colorCluster{
+		members: append(append([]int{}, a.members...), b.members...),
+		weight:  total,
+		lab: cielab{
+			L: (a.lab.L*a.weight + b.lab.L*b.weight) / total,
+			A: (a.lab.A*a.weight + b.lab.A*b.weight) / total,
+			B: (a.lab.B*a.weight + b.lab.B*b.weight) / total,
+		},
+		rep: a.rep,
+	}
+	sort.Ints(merged.members)
+
+	memberWeight := func(i int) float64 {
+		if len(weights) == 0 {
+			return 1
+		}
+		return weights[i]
+	}
+	if memberWeight(b.rep) > memberWeight(a.rep) {
+		merged.rep = b.rep
+	}
+
+	return merged
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errRevisionNotFound = errors.New("revision not found")
+
+type PaletteRevisionData struct {
+	Revision  int       `json:"revision"`
+	Name      string    `json:"name"`
+	Palette   []Color   `json:"palette"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type GetPaletteRevisionsResponse struct {
+	Revisions []PaletteRevisionData `json:"revisions"`
+}
+
+func revisionLimit() int {
+	return getEnvInt("PALETTE_REVISION_LIMIT", 50)
+}
+
+func revisionMaxAge() time.Duration {
+	return time.Duration(getEnvInt("PALETTE_REVISION_MAX_AGE_DAYS", 180)) * 24 * time.Hour
+}
+
+func (s *Server) getPaletteRevisionsHandler(c *gin.Context) {
+	paletteID := c.Param("id")
+	if paletteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Palette ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to get palette revisions"})
+		return
+	}
+
+	revisions, err := s.getUserPaletteRevisions(userID, paletteID)
+	if err != nil {
+		if errors.Is(err, errPaletteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch palette revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, GetPaletteRevisionsResponse{Revisions: revisions})
+}
+
+func (s *Server) revertPaletteHandler(c *gin.Context) {
+	paletteID := c.Param("id")
+	revision, err := strconv.Atoi(c.Param("rev"))
+	if paletteID == "" || err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Palette ID and revision number are required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to revert palettes"})
+		return
+	}
+
+	palette, err := s.revertUserPalette(userID, paletteID, revision)
+	if err != nil {
+		switch {
+		case errors.Is(err, errPaletteNotFound), errors.Is(err, errRevisionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errSystemPalette):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, errPaletteConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revert palette"})
+		}
+		return
+	}
+
+	c.Header("ETag", versionETag(palette.UpdatedAt))
+	c.JSON(http.StatusOK, palette)
+}
+
+func createPaletteRevision(tx *gorm.DB, palette *Palette) error {
+	var latest int
+	if err := tx.Model(&PaletteRevision{}).
+		Where("palette_id = ?", palette.ID).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&latest).Error; err != nil {
+		return err
+	}
+
+	revision := PaletteRevision{
+		PaletteID: palette.ID,
+		Revision:  latest + 1,
+		Name:      palette.Name,
+		JsonData:  palette.JsonData,
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return err
+	}
+
+	return prunePaletteRevisions(tx, palette.ID, revision.Revision)
+}
+
+func prunePaletteRevisions(tx *gorm.DB, paletteID uint, latest int) error {
+	limit := revisionLimit()
+	if limit <= 0 {
+		return nil
+	}
+
+	return tx.Where("palette_id = ? AND revision <= ?", paletteID, latest-limit).
+		Delete(&PaletteRevision{}).Error
+}
+
+func (s *Server) getUserPaletteRevisions(userID uint, paletteID string) ([]PaletteRevisionData, error) {
+	palette, err := s.Palettes.Get(userID, paletteID)
+	if err != nil {
+		return nil, err
+	}
+
+	dbRevisions, err := s.Palettes.Revisions(palette.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]PaletteRevisionData, 0, len(dbRevisions))
+	for _, dbRevision := range dbRevisions {
+		var colors []Color
+		if err := json.Unmarshal([]byte(dbRevision.JsonData), &colors); err != nil {
+			continue
+		}
+
+		revisions = append(revisions, PaletteRevisionData{
+			Revision:  dbRevision.Revision,
+			Name:      dbRevision.Name,
+			Palette:   colors,
+			CreatedAt: dbRevision.CreatedAt,
+		})
+	}
+
+	return revisions, nil
+}
+
+// Reverting is itself a change, so the state being replaced becomes a new
+// revision and history stays append-only.
+func (s *Server) revertUserPalette(userID uint, paletteID string, revision int) (*PaletteData, error) {
+	palette, err := s.Palettes.Get(userID, paletteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if palette.IsSystem {
+		return nil, errSystemPalette
+	}
+
+	target, err := s.Palettes.GetRevision(palette.ID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Palettes.Update(palette, map[string]any{
+		"name":      target.Name,
+		"json_data": target.JsonData,
+	}); err != nil {
+		return nil, err
+	}
+
+	var colors []Color
+	if err := json.Unmarshal([]byte(palette.JsonData), &colors); err != nil {
+		return nil, fmt.Errorf("failed to parse palette data")
+	}
+
+	data := toPaletteData(*palette, colors)
+	return &data, nil
+}
+
+func purgeExpiredPaletteRevisions() error {
+	if DB == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	maxAge := revisionMaxAge()
+	if maxAge <= 0 {
+		return nil
+	}
+
+	result := DB.Where("created_at < ?", DB.NowFunc().Add(-maxAge)).Delete(&PaletteRevision{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Purged %d expired palette revisions", result.RowsAffected)
+	}
+
+	return nil
+}
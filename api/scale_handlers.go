@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var scaleSteps = []int{50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 950}
+
+const (
+	scaleLightest = 0.97
+	scaleDarkest  = 0.26
+)
+
+type PaletteScalesRequest struct {
+	Palette   []Color `json:"palette"`
+	PaletteID string  `json:"paletteId"`
+	Format    string  `json:"format"`
+}
+
+type ScaleShade struct {
+	Step int    `json:"step"`
+	Hex  string `json:"hex"`
+}
+
+type ColorScale struct {
+	Name     string       `json:"name"`
+	Base     string       `json:"base"`
+	BaseStep int          `json:"baseStep"`
+	Shades   []ScaleShade `json:"shades"`
+}
+
+type PaletteScalesResponse struct {
+	Scales   []ColorScale `json:"scales"`
+	Tailwind string       `json:"tailwind,omitempty"`
+}
+
+func (s *Server) paletteScalesHandler(c *gin.Context) {
+	var req PaletteScalesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Format != "" && req.Format != "json" && req.Format != "tailwind" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be json or tailwind"})
+		return
+	}
+
+	palette, ok := s.resolvePaletteColors(c, req.Palette, req.PaletteID)
+	if !ok {
+		return
+	}
+
+	scales, err := generatePaletteScales(palette)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := PaletteScalesResponse{Scales: scales}
+	if req.Format == "tailwind" {
+		resp.Tailwind = formatTailwindColors(scales)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func generatePaletteScales(palette []Color) ([]ColorScale, error) {
+	rgbas, err := parsePaletteRGBAs(palette)
+	if err != nil {
+		return nil, err
+	}
+
+	scales := make([]ColorScale, len(rgbas))
+	used := make(map[string]bool, len(rgbas))
+	for i, rgba := range rgbas {
+		base := rgbaToOklch(rgba)
+		shades, baseStep := generateScale(base)
+		scales[i] = ColorScale{
+			Name:     uniqueScaleName(scaleName(palette[i].Name, i), used),
+			Base:     rgbaToHex(rgba),
+			BaseStep: baseStep,
+			Shades:   shades,
+		}
+	}
+
+	return scales, nil
+}
+
+func scaleName(name string, index int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteByte('-')
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return fmt.Sprintf("color-%d", index+1)
+	}
+	return slug
+}
+
+// uniqueScaleName suffixes names already in use with -2, -3, ..., since the
+// names become Tailwind keys and a repeated key would replace the earlier
+// scale.
+func uniqueScaleName(name string, used map[string]bool) string {
+	candidate := name
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s-%d", name, n)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// Lightness is spaced evenly from scaleLightest to scaleDarkest so every
+// palette gets the same perceptual rhythm; chroma follows the base color and
+// tapers toward both ends, where sRGB cannot hold much of it anyway.
+func generateScale(base oklch) ([]ScaleShade, int) {
+	shades := make([]ScaleShade, len(scaleSteps))
+	baseStep := scaleSteps[0]
+	closest := math.MaxFloat64
+
+	last := float64(len(scaleSteps) - 1)
+	for i, step := range scaleSteps {
+		t := float64(i) / last
+		lightness := scaleLightest - t*(scaleLightest-scaleDarkest)
+		chroma := base.C * (0.35 + 0.65*math.Sin(math.Pi*t))
+
+		rgba := oklchToRGBA(oklch{L: lightness, C: chroma, H: base.H})
+		shades[i] = ScaleShade{Step: step, Hex: rgbaToHex(rgba)}
+
+		if d := math.Abs(lightness - base.L); d < closest {
+			closest = d
+			baseStep = step
+		}
+	}
+
+	return shades, baseStep
+}
+
+func formatTailwindColors(scales []ColorScale) string {
+	var b strings.Builder
+	b.WriteString("module.exports = {\n  theme: {\n    extend: {\n      colors: {\n")
+	for _, scale := range scales {
+		fmt.Fprintf(&b, "        '%s': {\n", scale.Name)
+		for _, shade := range scale.Shades {
+			fmt.Fprintf(&b, "          %d: '%s',\n", shade.Step, shade.Hex)
+		}
+		b.WriteString("        },\n")
+	}
+	b.WriteString("      },\n    },\n  },\n};\n")
+	return b.String()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"gorm.io/gorm"
+)
+
+// Server holds the stores and the blob store handlers persist through, so
+// tests can run the handlers against in-memory fakes. Only the jobs started
+// from main.go and runMaintenance (migrations, the image and color backfills
+// and the purges) use DB and Blobs directly.
+type Server struct {
+	Palettes    PaletteStore
+	Gallery     GalleryStore
+	Workspaces  WorkspaceStore
+	Collections CollectionStore
+	Users       UserStore
+	// Blobs is nil when no blob store could be opened; workspace images
+	// are then unavailable.
+	Blobs BlobStore
+}
+
+func newServer(db *gorm.DB, blobs BlobStore) *Server {
+	return &Server{
+		Palettes:    &gormPaletteStore{db: db},
+		Gallery:     &gormGalleryStore{db: db},
+		Workspaces:  &gormWorkspaceStore{db: db, blobs: blobs},
+		Collections: &gormCollectionStore{db: db},
+		Users:       &gormUserStore{db: db},
+		Blobs:       blobs,
+	}
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errShareNotFound = errors.New("shared workspace not found")
+	errShareExpired  = errors.New("share link has expired")
+	errSharePassword = errors.New("share link requires a valid password")
+)
+
+// sharePasswordHeader carries the password for protected share links, so it
+// stays out of URLs and server logs.
+const sharePasswordHeader = "X-Share-Password"
+
+type ShareWorkspaceRequest struct {
+	ExpiresAt *time.Time `json:"expiresAt"`
+	Password  string     `json:"password" binding:"max=72"`
+}
+
+// WorkspaceShare describes an active share link to the workspace owner.
+type WorkspaceShare struct {
+	ShareToken        string     `json:"shareToken"`
+	ShareURL          string     `json:"shareUrl"`
+	ExpiresAt         *time.Time `json:"expiresAt,omitempty"`
+	PasswordProtected bool       `json:"passwordProtected"`
+	Views             int        `json:"views"`
+}
+
+func generateShareToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// shareURL builds the link handed out to viewers. SHARE_BASE_URL is the
+// public address of the frontend, which opens shared workspaces via ?share=.
+func shareURL(token string) string {
+	base := strings.TrimSuffix(getEnv("SHARE_BASE_URL", "http://localhost:5173"), "/")
+	return base + "/?share=" + token
+}
+
+func toWorkspaceShare(workspace Workspace) *WorkspaceShare {
+	if workspace.ShareToken == nil {
+		return nil
+	}
+	return &WorkspaceShare{
+		ShareToken:        *workspace.ShareToken,
+		ShareURL:          shareURL(*workspace.ShareToken),
+		ExpiresAt:         workspace.ShareExpiresAt,
+		PasswordProtected: workspace.SharePasswordHash != "",
+		Views:             workspace.ShareViews,
+	}
+}
+
+func (s *Server) shareWorkspaceHandler(c *gin.Context) {
+	workspaceID := c.Param("id")
+	if workspaceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace ID is required"})
+		return
+	}
+
+	// The body is optional: an empty POST shares without expiry or password.
+	var req ShareWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be in the future"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to share workspaces"})
+		return
+	}
+
+	share, err := s.createWorkspaceShare(userID, workspaceID, req)
+	if err != nil {
+		if errors.Is(err, errWorkspaceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, share)
+}
+
+func (s *Server) getSharedWorkspaceHandler(c *gin.Context) {
+	shareToken := c.Param("token")
+	if shareToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Share token is required"})
+		return
+	}
+
+	workspace, err := s.getWorkspaceByShareToken(shareToken, c.GetHeader(sharePasswordHeader))
+	if err != nil {
+		respondShareError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspace)
+}
+
+func (s *Server) forkSharedWorkspaceHandler(c *gin.Context) {
+	shareToken := c.Param("token")
+	if shareToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Share token is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to fork workspaces"})
+		return
+	}
+
+	workspace, err := s.forkSharedWorkspace(userID, shareToken, c.GetHeader(sharePasswordHeader))
+	if err != nil {
+		respondShareError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, workspace)
+}
+
+func respondShareError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errShareNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shared workspace not found"})
+	case errors.Is(err, errShareExpired):
+		c.JSON(http.StatusGone, gin.H{"error": "This share link has expired"})
+	case errors.Is(err, errSharePassword):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "This share link is password protected", "passwordRequired": true})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (s *Server) removeWorkspaceShareHandler(c *gin.Context) {
+	workspaceID := c.Param("id")
+	if workspaceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	err := s.removeWorkspaceShareToken(userID, workspaceID)
+	if err != nil {
+		if errors.Is(err, errWorkspaceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share link removed successfully"})
+}
+
+// createWorkspaceShare creates a share link or updates the settings of the
+// existing one. The token, and with it the view count, is kept when a link
+// already exists so previously handed out URLs keep working.
+//
+// Share settings are not an edit and leave updated_at alone; bumping it
+// would make open editors see a spurious conflict.
+func (s *Server) createWorkspaceShare(userID uint, workspaceID string, req ShareWorkspaceRequest) (*WorkspaceShare, error) {
+	workspace, err := s.Workspaces.Get(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if workspace.ShareToken == nil {
+		shareToken, err := generateShareToken()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate share token")
+		}
+		workspace.ShareToken = &shareToken
+		workspace.ShareViews = 0
+	}
+
+	workspace.SharePasswordHash = ""
+	if req.Password != "" {
+		hash, err := hashPassword(req.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash share password")
+		}
+		workspace.SharePasswordHash = hash
+	}
+	workspace.ShareExpiresAt = nil
+	if req.ExpiresAt != nil {
+		expiresAt := req.ExpiresAt.UTC()
+		workspace.ShareExpiresAt = &expiresAt
+	}
+
+	if err := s.Workspaces.UpdateShare(workspace); err != nil {
+		return nil, fmt.Errorf("failed to save share token")
+	}
+
+	return toWorkspaceShare(*workspace), nil
+}
+
+// checkShareAccess rejects expired links even if the maintenance purge has
+// not removed them yet, and links whose password does not match.
+func checkShareAccess(workspace *Workspace, password string, now time.Time) error {
+	if workspace.ShareExpiresAt != nil && !workspace.ShareExpiresAt.After(now) {
+		return errShareExpired
+	}
+	if workspace.SharePasswordHash != "" && !checkPasswordHash(password, workspace.SharePasswordHash) {
+		return errSharePassword
+	}
+	return nil
+}
+
+// getWorkspaceByShareToken returns the read-only view of a shared workspace
+// and counts the view.
+func (s *Server) getWorkspaceByShareToken(shareToken, password string) (*WorkspaceData, error) {
+	dbWorkspace, err := s.Workspaces.GetByShareToken(shareToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkShareAccess(dbWorkspace, password, time.Now()); err != nil {
+		return nil, err
+	}
+
+	workspace, err := s.toWorkspaceData(*dbWorkspace)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Workspaces.CountShareView(dbWorkspace.ID); err != nil {
+		log.Printf("Failed to count view of shared workspace %d: %v", dbWorkspace.ID, err)
+	}
+
+	// Viewers get a copy to work with, not the owner's share settings.
+	workspace.ShareToken = nil
+	workspace.ForkCount = 0
+	workspace.ReadOnly = true
+	return workspace, nil
+}
+
+// forkSharedWorkspace copies a shared workspace into the user's account. Image
+// and thumbnail blobs are content-addressed, so the copy refers to the same
+// objects instead of duplicating them; releaseBlob only deletes a blob once
+// no workspace uses it.
+func (s *Server) forkSharedWorkspace(userID uint, shareToken, password string) (*WorkspaceData, error) {
+	source, err := s.Workspaces.GetByShareToken(shareToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkShareAccess(source, password, time.Now()); err != nil {
+		return nil, err
+	}
+
+	fork := Workspace{
+		UserID:        &userID,
+		Name:          source.Name,
+		JsonData:      source.JsonData,
+		ImageData:     source.ImageData,
+		ImageHash:     source.ImageHash,
+		ImageType:     source.ImageType,
+		ThumbnailHash: source.ThumbnailHash,
+		Tags:          source.Tags,
+		ForkedFromID:  &source.ID,
+	}
+	if err := s.Workspaces.Fork(&fork); err != nil {
+		return nil, err
+	}
+
+	return s.toWorkspaceData(fork)
+}
+
+func (s *Server) removeWorkspaceShareToken(userID uint, workspaceID string) error {
+	workspace, err := s.Workspaces.Get(userID, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	workspace.ShareToken = nil
+	workspace.ShareExpiresAt = nil
+	workspace.SharePasswordHash = ""
+	workspace.ShareViews = 0
+	if err := s.Workspaces.UpdateShare(workspace); err != nil {
+		return fmt.Errorf("failed to remove share token")
+	}
+
+	return nil
+}
+
+// purgeExpiredShareLinks revokes share links past their expiry date.
+func purgeExpiredShareLinks() error {
+	if DB == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	result := DB.Model(&Workspace{}).Where("share_expires_at < ?", DB.NowFunc()).UpdateColumns(map[string]any{
+		"share_token":         nil,
+		"share_expires_at":    nil,
+		"share_password_hash": "",
+		"share_views":         0,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Revoked %d expired share links", result.RowsAffected)
+	}
+
+	return nil
+}
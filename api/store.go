@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	errDatabaseUnavailable = errors.New("database not available")
+	errUserNotFound        = errors.New("user not found")
+)
+
+// PaletteStore persists palettes. Lookups by user only see that user's
+// palettes outside the trash and report anything else as errPaletteNotFound.
+type PaletteStore interface {
+	Create(palette *Palette) error
+	Get(userID uint, paletteID string) (*Palette, error)
+	// List returns one page of the user's palettes, newest first, and the
+	// cursor of the next page ("" on the last one).
+	List(userID uint, filter ListFilter, page PageParams) ([]Palette, string, error)
+	// Update applies updates only if the palette still carries the
+	// UpdatedAt it was loaded with, and errPaletteConflict otherwise. The
+	// name and colors being replaced are kept as a revision, and palette is
+	// reloaded afterwards.
+	Update(palette *Palette, updates map[string]any) error
+	// Delete moves the palette to the trash.
+	Delete(palette *Palette) error
+	// SetShareToken sets or, with nil, clears the share token without
+	// changing updated_at.
+	SetShareToken(palette *Palette, token *string) error
+	GetByShareToken(token string) (*Palette, error)
+	// SetPublished puts the palette in the gallery, keeping the date it was
+	// first published, or takes it out. updated_at is left alone.
+	SetPublished(palette *Palette, published bool) error
+	// Revisions returns the palette's revisions, newest first.
+	Revisions(paletteID uint) ([]PaletteRevision, error)
+	// GetRevision returns errRevisionNotFound for unknown revisions.
+	GetRevision(paletteID uint, revision int) (*PaletteRevision, error)
+	// ListDeleted returns the user's palettes in the trash.
+	ListDeleted(userID uint) ([]Palette, error)
+	// Restore takes a palette out of the trash without changing updated_at,
+	// or returns errTrashItemNotFound.
+	Restore(userID uint, paletteID string) error
+}
+
+// GalleryStore reads and likes published palettes. Lookups of palettes that
+// are not published return errGalleryPaletteNotFound.
+type GalleryStore interface {
+	// List returns one page of published palettes with their User loaded,
+	// in the given order.
+	List(filter ListFilter, page PageParams, sort pageSort) ([]Palette, string, error)
+	Get(paletteID string) (*Palette, error)
+	// Liked reports which of the palettes the user has liked.
+	Liked(userID uint, paletteIDs []uint) (map[uint]bool, error)
+	// SetLike likes or unlikes a palette and returns its like count after
+	// the change. Repeating either is a no-op.
+	SetLike(userID uint, paletteID string, liked bool) (int, error)
+	// Moderate takes a palette out of the gallery and marks it so its owner
+	// cannot publish it again.
+	Moderate(paletteID string) error
+}
+
+// WorkspaceStore persists workspaces, with the same ownership and trash rules
+// as PaletteStore and errWorkspaceNotFound for lookups that miss.
+type WorkspaceStore interface {
+	Create(workspace *Workspace) error
+	Get(userID uint, workspaceID string) (*Workspace, error)
+	// GetSummary is Get without the legacy inline image.
+	GetSummary(userID uint, workspaceID string) (*Workspace, error)
+	// List returns one page of the user's workspaces without the legacy
+	// inline image, newest first.
+	List(userID uint, filter ListFilter, page PageParams) ([]Workspace, string, error)
+	// Update applies updates only if the workspace still carries the
+	// UpdatedAt it was loaded with, and errWorkspaceConflict otherwise.
+	// workspace is reloaded afterwards, without the legacy inline image.
+	Update(workspace *Workspace, updates map[string]any) error
+	// SetThumbnailHash records a regenerated thumbnail without changing
+	// updated_at.
+	SetThumbnailHash(workspaceID uint, hash string) error
+	// Fork creates a copy of the workspace named by fork.ForkedFromID and
+	// counts it on the source.
+	Fork(fork *Workspace) error
+	// Delete moves the workspace to the trash.
+	Delete(workspace *Workspace) error
+	// UpdateShare writes the share_* columns of the workspace without
+	// changing updated_at.
+	UpdateShare(workspace *Workspace) error
+	// GetByShareToken returns errShareNotFound for unknown tokens. Expiry
+	// and passwords are checked by the caller.
+	GetByShareToken(token string) (*Workspace, error)
+	CountShareView(workspaceID uint) error
+	// ListDeleted returns the user's workspaces in the trash.
+	ListDeleted(userID uint) ([]Workspace, error)
+	// Restore takes a workspace out of the trash without changing
+	// updated_at, or returns errTrashItemNotFound.
+	Restore(userID uint, workspaceID string) error
+	// ReleaseBlobs deletes the image and thumbnail blobs with these hashes
+	// unless a workspace still refers to them. Failures are only logged.
+	ReleaseBlobs(ctx context.Context, imageHash, thumbnailHash string)
+}
+
+// CollectionSummary is a collection with the number of palettes and
+// workspaces in it, not counting those in the trash.
+type CollectionSummary struct {
+	ID             uint
+	Name           string
+	PaletteCount   int
+	WorkspaceCount int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// CollectionStore persists collections. Lookups only see the user's own
+// collections and report anything else as errCollectionNotFound.
+type CollectionStore interface {
+	// List returns the user's collections by name.
+	List(userID uint) ([]CollectionSummary, error)
+	GetSummary(userID uint, collectionID string) (*CollectionSummary, error)
+	Get(userID uint, collectionID string) (*Collection, error)
+	// Create and Rename return errCollectionExists when the user already
+	// has another collection with the name.
+	Create(collection *Collection) error
+	Rename(collection *Collection, name string) error
+	// Delete removes the collection and its links, not its members.
+	Delete(collection *Collection) error
+	// AddMember and RemoveMember link or unlink a *Palette or *Workspace.
+	// Neither fails when there is nothing to do.
+	AddMember(collection *Collection, item any) error
+	RemoveMember(collection *Collection, item any) error
+}
+
+// UserStore persists accounts. Lookups that miss return errUserNotFound.
+type UserStore interface {
+	Create(user *User) error
+	Get(userID uint) (*User, error)
+	GetByEmail(email string) (*User, error)
+	UpdatePasswordHash(userID uint, hash string) error
+}
@@ -0,0 +1,752 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The GORM stores back the API in production. db is nil when the database
+// could not be opened, in which case every call fails with
+// errDatabaseUnavailable.
+
+type gormPaletteStore struct {
+	db *gorm.DB
+}
+
+type gormGalleryStore struct {
+	db *gorm.DB
+}
+
+// gormWorkspaceStore also owns the blobs workspaces refer to, since only it
+// can tell when the last reference is gone.
+type gormWorkspaceStore struct {
+	db    *gorm.DB
+	blobs BlobStore
+}
+
+type gormCollectionStore struct {
+	db *gorm.DB
+}
+
+type gormUserStore struct {
+	db *gorm.DB
+}
+
+func (s *gormPaletteStore) Create(palette *Palette) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(palette).Error; err != nil {
+			return err
+		}
+		return indexPaletteColors(tx, palette.ID, palette.JsonData)
+	})
+}
+
+func (s *gormPaletteStore) Get(userID uint, paletteID string) (*Palette, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	// IDs come straight from the URL, so a malformed one is just another
+	// palette that does not exist.
+	var palette Palette
+	if err := s.db.Where("id = ? AND user_id = ?", paletteID, userID).First(&palette).Error; err != nil {
+		return nil, errPaletteNotFound
+	}
+	return &palette, nil
+}
+
+func (s *gormPaletteStore) List(userID uint, filter ListFilter, page PageParams) ([]Palette, string, error) {
+	if s.db == nil {
+		return nil, "", errDatabaseUnavailable
+	}
+
+	query := applyListFilter(s.db.Model(&Palette{}).Where("user_id = ?", userID), filter, paletteList)
+	if filter.CollectionID != 0 {
+		query = query.Where("id IN (?)", collectionMembers(s.db, "collection_palettes", "palette_id", filter.CollectionID))
+	}
+	return fetchPage(query, page, sortByCreated,
+		func(p Palette) (int64, uint) { return timeKey(p.CreatedAt), p.ID })
+}
+
+// Every palette change goes through here, so the revision is written in the
+// same transaction as the change it records.
+func (s *gormPaletteStore) Update(palette *Palette, updates map[string]any) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	updates["updated_at"] = s.db.NowFunc()
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Palette{}).
+			Where("id = ? AND updated_at = ?", palette.ID, palette.UpdatedAt).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errPaletteConflict
+		}
+
+		if err := createPaletteRevision(tx, palette); err != nil {
+			return err
+		}
+		if jsonData, ok := updates["json_data"].(string); ok {
+			if err := indexPaletteColors(tx, palette.ID, jsonData); err != nil {
+				return err
+			}
+		}
+
+		return tx.First(palette, palette.ID).Error
+	})
+}
+
+// Revisions, likes and collection membership stay with a deleted palette so
+// a restore brings everything back; purgeExpiredTrash removes them for good.
+func (s *gormPaletteStore) Delete(palette *Palette) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return s.db.Delete(palette).Error
+}
+
+func (s *gormPaletteStore) SetShareToken(palette *Palette, token *string) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	if err := s.db.Model(palette).UpdateColumn("share_token", token).Error; err != nil {
+		return err
+	}
+	palette.ShareToken = token
+	return nil
+}
+
+func (s *gormPaletteStore) GetByShareToken(token string) (*Palette, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var palette Palette
+	if err := s.db.Where("share_token = ?", token).First(&palette).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errSharedPaletteNotFound
+		}
+		return nil, err
+	}
+	return &palette, nil
+}
+
+func (s *gormPaletteStore) SetPublished(palette *Palette, published bool) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+
+	publishedAt := palette.PublishedAt
+	switch {
+	case published && publishedAt == nil:
+		now := s.db.NowFunc()
+		publishedAt = &now
+	case !published:
+		publishedAt = nil
+	}
+
+	if err := s.db.Model(palette).UpdateColumn("published_at", publishedAt).Error; err != nil {
+		return err
+	}
+	palette.PublishedAt = publishedAt
+	return nil
+}
+
+func (s *gormPaletteStore) Revisions(paletteID uint) ([]PaletteRevision, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var revisions []PaletteRevision
+	err := s.db.Where("palette_id = ?", paletteID).Order("revision DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+func (s *gormPaletteStore) GetRevision(paletteID uint, revision int) (*PaletteRevision, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var target PaletteRevision
+	if err := s.db.Where("palette_id = ? AND revision = ?", paletteID, revision).First(&target).Error; err != nil {
+		return nil, errRevisionNotFound
+	}
+	return &target, nil
+}
+
+func (s *gormPaletteStore) ListDeleted(userID uint) ([]Palette, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var palettes []Palette
+	err := s.db.Unscoped().Select("id, name, deleted_at").
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Find(&palettes).Error
+	return palettes, err
+}
+
+func (s *gormPaletteStore) Restore(userID uint, paletteID string) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return restoreDeleted(s.db, &Palette{}, userID, paletteID)
+}
+
+// restoreDeleted clears deleted_at with UpdateColumn, which leaves
+// updated_at and with it the version open editors last saw.
+func restoreDeleted(db *gorm.DB, model any, userID uint, id string) error {
+	result := db.Unscoped().Model(model).
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+		UpdateColumn("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errTrashItemNotFound
+	}
+	return nil
+}
+
+func (s *gormGalleryStore) List(filter ListFilter, page PageParams, sort pageSort) ([]Palette, string, error) {
+	if s.db == nil {
+		return nil, "", errDatabaseUnavailable
+	}
+
+	query := applyListFilter(s.db.Model(&Palette{}).Preload("User").Where("published_at IS NOT NULL"), filter, paletteList)
+	return fetchPage(query, page, sort,
+		func(p Palette) (int64, uint) {
+			if sort.column == sortByLikes.column {
+				return int64(p.LikeCount), p.ID
+			}
+			return timeKey(*p.PublishedAt), p.ID
+		},
+	)
+}
+
+func (s *gormGalleryStore) Get(paletteID string) (*Palette, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+	return getPublishedPalette(s.db, paletteID)
+}
+
+func getPublishedPalette(tx *gorm.DB, paletteID string) (*Palette, error) {
+	var palette Palette
+	if err := tx.Where("id = ? AND published_at IS NOT NULL", paletteID).First(&palette).Error; err != nil {
+		return nil, errGalleryPaletteNotFound
+	}
+	return &palette, nil
+}
+
+func (s *gormGalleryStore) Liked(userID uint, paletteIDs []uint) (map[uint]bool, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var likedIDs []uint
+	if err := s.db.Model(&PaletteLike{}).
+		Where("user_id = ? AND palette_id IN ?", userID, paletteIDs).
+		Pluck("palette_id", &likedIDs).Error; err != nil {
+		return nil, err
+	}
+
+	liked := make(map[uint]bool, len(likedIDs))
+	for _, id := range likedIDs {
+		liked[id] = true
+	}
+	return liked, nil
+}
+
+func (s *gormGalleryStore) SetLike(userID uint, paletteID string, liked bool) (int, error) {
+	if s.db == nil {
+		return 0, errDatabaseUnavailable
+	}
+
+	var likeCount int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		palette, err := getPublishedPalette(tx, paletteID)
+		if err != nil {
+			return err
+		}
+
+		like := PaletteLike{UserID: userID, PaletteID: palette.ID}
+		var result *gorm.DB
+		delta := 1
+		if liked {
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&like)
+		} else {
+			result = tx.Where("user_id = ? AND palette_id = ?", userID, palette.ID).Delete(&PaletteLike{})
+			delta = -1
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected > 0 {
+			if err := tx.Model(palette).
+				UpdateColumn("like_count", gorm.Expr("like_count + ?", delta)).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&Palette{}).Where("id = ?", palette.ID).Pluck("like_count", &likeCount).Error
+	})
+
+	return likeCount, err
+}
+
+func (s *gormGalleryStore) Moderate(paletteID string) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+
+	palette, err := getPublishedPalette(s.db, paletteID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Model(palette).UpdateColumns(map[string]any{
+		"published_at": nil,
+		"moderated_at": s.db.NowFunc(),
+	}).Error
+}
+
+func (s *gormWorkspaceStore) Create(workspace *Workspace) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBlobs(tx, workspaceBlobKeys(workspace)...); err != nil {
+			return err
+		}
+		if err := tx.Create(workspace).Error; err != nil {
+			return err
+		}
+		state, err := parseWorkspaceState(workspace.JsonData)
+		if err != nil {
+			return nil
+		}
+		return indexWorkspaceColors(tx, workspace.ID, state.Colors)
+	})
+}
+
+func (s *gormWorkspaceStore) Get(userID uint, workspaceID string) (*Workspace, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var workspace Workspace
+	if err := s.db.Where("id = ? AND user_id = ?", workspaceID, userID).First(&workspace).Error; err != nil {
+		return nil, errWorkspaceNotFound
+	}
+	return &workspace, nil
+}
+
+func (s *gormWorkspaceStore) GetSummary(userID uint, workspaceID string) (*Workspace, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var workspace Workspace
+	if err := s.db.Select(workspaceSummaryColumns).
+		Where("id = ? AND user_id = ?", workspaceID, userID).
+		First(&workspace).Error; err != nil {
+		return nil, errWorkspaceNotFound
+	}
+	return &workspace, nil
+}
+
+func (s *gormWorkspaceStore) List(userID uint, filter ListFilter, page PageParams) ([]Workspace, string, error) {
+	if s.db == nil {
+		return nil, "", errDatabaseUnavailable
+	}
+
+	query := applyListFilter(s.db.Model(&Workspace{}).Select(workspaceSummaryColumns).Where("user_id = ?", userID), filter, workspaceList)
+	if filter.CollectionID != 0 {
+		query = query.Where("id IN (?)", collectionMembers(s.db, "collection_workspaces", "workspace_id", filter.CollectionID))
+	}
+	return fetchPage(query, page, sortByCreated,
+		func(w Workspace) (int64, uint) { return timeKey(w.CreatedAt), w.ID })
+}
+
+// The blobs an update points the workspace at are locked along with the
+// write; see releaseBlob.
+func (s *gormWorkspaceStore) Update(workspace *Workspace, updates map[string]any) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	updates["updated_at"] = s.db.NowFunc()
+
+	var blobKeys []string
+	if hash, _ := updates["image_hash"].(string); hash != "" {
+		blobKeys = append(blobKeys, imageBlobKey(hash))
+	}
+	if hash, _ := updates["thumbnail_hash"].(string); hash != "" {
+		blobKeys = append(blobKeys, thumbnailBlobKey(hash))
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBlobs(tx, blobKeys...); err != nil {
+			return err
+		}
+		result := tx.Model(&Workspace{}).
+			Where("id = ? AND updated_at = ?", workspace.ID, workspace.UpdatedAt).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errWorkspaceConflict
+		}
+		if jsonData, ok := updates["json_data"].(string); ok {
+			if state, err := parseWorkspaceState(jsonData); err == nil {
+				return indexWorkspaceColors(tx, workspace.ID, state.Colors)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var reloaded Workspace
+	if err := s.db.Select(workspaceSummaryColumns).First(&reloaded, workspace.ID).Error; err != nil {
+		return err
+	}
+	*workspace = reloaded
+	return nil
+}
+
+func (s *gormWorkspaceStore) SetThumbnailHash(workspaceID uint, hash string) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBlobs(tx, thumbnailBlobKey(hash)); err != nil {
+			return err
+		}
+		return tx.Model(&Workspace{}).Where("id = ?", workspaceID).UpdateColumn("thumbnail_hash", hash).Error
+	})
+}
+
+// The fork refers to the same blobs as its source. Locking them keeps a
+// purge of the source from releasing them until the fork is in place, and
+// counting the fork fails if the source went first.
+func (s *gormWorkspaceStore) Fork(fork *Workspace) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBlobs(tx, workspaceBlobKeys(fork)...); err != nil {
+			return err
+		}
+		if err := tx.Create(fork).Error; err != nil {
+			return err
+		}
+		if state, err := parseWorkspaceState(fork.JsonData); err == nil {
+			if err := indexWorkspaceColors(tx, fork.ID, state.Colors); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&Workspace{}).Where("id = ?", *fork.ForkedFromID).
+			UpdateColumn("fork_count", gorm.Expr("fork_count + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errShareNotFound
+		}
+		return nil
+	})
+}
+
+// Blobs and collection membership stay with a deleted workspace;
+// purgeExpiredTrash releases them once the retention runs out.
+func (s *gormWorkspaceStore) Delete(workspace *Workspace) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return s.db.Delete(workspace).Error
+}
+
+func (s *gormWorkspaceStore) UpdateShare(workspace *Workspace) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return s.db.Model(&Workspace{}).Where("id = ?", workspace.ID).UpdateColumns(map[string]any{
+		"share_token":         workspace.ShareToken,
+		"share_expires_at":    workspace.ShareExpiresAt,
+		"share_password_hash": workspace.SharePasswordHash,
+		"share_views":         workspace.ShareViews,
+	}).Error
+}
+
+func (s *gormWorkspaceStore) GetByShareToken(token string) (*Workspace, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var workspace Workspace
+	if err := s.db.Where("share_token = ?", token).First(&workspace).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errShareNotFound
+		}
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+func (s *gormWorkspaceStore) CountShareView(workspaceID uint) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return s.db.Model(&Workspace{}).Where("id = ?", workspaceID).
+		UpdateColumn("share_views", gorm.Expr("share_views + 1")).Error
+}
+
+func (s *gormWorkspaceStore) ListDeleted(userID uint) ([]Workspace, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var workspaces []Workspace
+	err := s.db.Unscoped().Select("id, name, deleted_at").
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Find(&workspaces).Error
+	return workspaces, err
+}
+
+func (s *gormWorkspaceStore) Restore(userID uint, workspaceID string) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return restoreDeleted(s.db, &Workspace{}, userID, workspaceID)
+}
+
+func (s *gormWorkspaceStore) ReleaseBlobs(ctx context.Context, imageHash, thumbnailHash string) {
+	releaseWorkspaceBlobs(ctx, s.db, s.blobs, imageHash, thumbnailHash)
+}
+
+// collectionSummaries selects collections with their member counts. Members
+// in the trash are left out, as they are when listing a collection.
+func collectionSummaries(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Model(&Collection{}).
+		Select("id, name, created_at, updated_at, "+
+			"(SELECT COUNT(*) FROM collection_palettes JOIN palettes ON palettes.id = collection_palettes.palette_id "+
+			"WHERE collection_id = collections.id AND palettes.deleted_at IS NULL) AS palette_count, "+
+			"(SELECT COUNT(*) FROM collection_workspaces JOIN workspaces ON workspaces.id = collection_workspaces.workspace_id "+
+			"WHERE collection_id = collections.id AND workspaces.deleted_at IS NULL) AS workspace_count").
+		Where("user_id = ?", userID)
+}
+
+func (s *gormCollectionStore) List(userID uint) ([]CollectionSummary, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var collections []CollectionSummary
+	err := collectionSummaries(s.db, userID).Order("name").Scan(&collections).Error
+	return collections, err
+}
+
+func (s *gormCollectionStore) GetSummary(userID uint, collectionID string) (*CollectionSummary, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var collections []CollectionSummary
+	if err := collectionSummaries(s.db, userID).Where("id = ?", collectionID).Scan(&collections).Error; err != nil {
+		return nil, err
+	}
+	if len(collections) == 0 {
+		return nil, errCollectionNotFound
+	}
+	return &collections[0], nil
+}
+
+func (s *gormCollectionStore) Get(userID uint, collectionID string) (*Collection, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var collection Collection
+	if err := s.db.Where("id = ? AND user_id = ?", collectionID, userID).First(&collection).Error; err != nil {
+		return nil, errCollectionNotFound
+	}
+	return &collection, nil
+}
+
+func (s *gormCollectionStore) nameTaken(userID uint, name string, exceptID uint) (bool, error) {
+	var count int64
+	err := s.db.Model(&Collection{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *gormCollectionStore) Create(collection *Collection) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+
+	taken, err := s.nameTaken(collection.UserID, collection.Name, 0)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return errCollectionExists
+	}
+	// The check above gives the usual answer; the unique index catches a
+	// collection created with the same name in between.
+	if err := s.db.Create(collection).Error; err != nil {
+		if isDuplicateKey(s.db, err) {
+			return errCollectionExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *gormCollectionStore) Rename(collection *Collection, name string) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+
+	taken, err := s.nameTaken(collection.UserID, name, collection.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return errCollectionExists
+	}
+	if err := s.db.Model(collection).Update("name", name).Error; err != nil {
+		if isDuplicateKey(s.db, err) {
+			return errCollectionExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *gormCollectionStore) Delete(collection *Collection) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(collection).Association("Palettes").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Model(collection).Association("Workspaces").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(collection).Error
+	})
+}
+
+func (s *gormCollectionStore) AddMember(collection *Collection, item any) error {
+	association, err := s.memberAssociation(collection, item)
+	if err != nil {
+		return err
+	}
+	return association.Append(item)
+}
+
+func (s *gormCollectionStore) RemoveMember(collection *Collection, item any) error {
+	association, err := s.memberAssociation(collection, item)
+	if err != nil {
+		return err
+	}
+	return association.Delete(item)
+}
+
+// collectionMembers selects the member ids of a collection from the join
+// table gorm creates for the many2many association, for use as an
+// "id IN (?)" subquery when listing palettes or workspaces.
+func collectionMembers(db *gorm.DB, joinTable, joinColumn string, collectionID uint) *gorm.DB {
+	return db.Table(joinTable).Select(joinColumn).Where("collection_id = ?", collectionID)
+}
+
+// isDuplicateKey reports whether err is a unique index violation. The
+// connection does not translate errors, so the dialect is asked here.
+func isDuplicateKey(db *gorm.DB, err error) bool {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// memberAssociation only touches the join table: Omit keeps gorm from
+// upserting the member itself.
+func (s *gormCollectionStore) memberAssociation(collection *Collection, item any) (*gorm.Association, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var name string
+	switch item.(type) {
+	case *Palette:
+		name = "Palettes"
+	case *Workspace:
+		name = "Workspaces"
+	default:
+		return nil, fmt.Errorf("cannot add %T to a collection", item)
+	}
+	return s.db.Model(collection).Omit(name + ".*").Association(name), nil
+}
+
+func (s *gormUserStore) Create(user *User) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return s.db.Create(user).Error
+}
+
+func (s *gormUserStore) Get(userID uint) (*User, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var user User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *gormUserStore) GetByEmail(email string) (*User, error) {
+	if s.db == nil {
+		return nil, errDatabaseUnavailable
+	}
+
+	var user User
+	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *gormUserStore) UpdatePasswordHash(userID uint, hash string) error {
+	if s.db == nil {
+		return errDatabaseUnavailable
+	}
+	return s.db.Model(&User{}).Where("id = ?", userID).Update("password_hash", hash).Error
+}
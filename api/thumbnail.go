@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"net/http"
+
+	xdraw "golang.org/x/image/draw"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultThumbnailSize = 256
+	thumbnailStripHeight = 16
+
+	// Decoding needs several bytes per pixel, so larger images are not
+	// rendered at all. THUMBNAIL_MAX_PIXELS overrides it.
+	defaultThumbnailMaxPixels = 40_000_000
+
+	// thumbnailUnavailable is stored as the thumbnail hash of a workspace
+	// whose image cannot be rendered, so requests for its thumbnail do not
+	// decode the image again. Saving a new image or state tries again.
+	thumbnailUnavailable = "unavailable"
+
+	// The editor canvas fits images into this box (CANVAS in the frontend's
+	// constants.ts) and selector rectangles are stored in canvas coordinates.
+	editorCanvasMaxWidth  = 800
+	editorCanvasMaxHeight = 400
+)
+
+// ThumbnailOptions controls the overlays drawn on a workspace thumbnail. It is
+// kept with the workspace state so regenerated thumbnails look the same.
+type ThumbnailOptions struct {
+	Selectors bool `json:"selectors"`
+	Palette   bool `json:"palette"`
+}
+
+var defaultThumbnailOptions = ThumbnailOptions{Selectors: true, Palette: true}
+
+var errImageTooLarge = errors.New("image is too large to render a thumbnail")
+
+func thumbnailBlobKey(hash string) string {
+	return "thumbnails/" + hash
+}
+
+func (s *Server) getWorkspaceThumbnailHandler(c *gin.Context) {
+	workspaceID := c.Param("id")
+	if workspaceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to get workspaces"})
+		return
+	}
+
+	workspace, err := s.Workspaces.GetSummary(userID, workspaceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errWorkspaceNotFound.Error()})
+		return
+	}
+
+	// Workspaces saved before thumbnails existed get one on first request.
+	if workspace.ThumbnailHash == "" {
+		if err := s.backfillWorkspaceThumbnail(c.Request.Context(), userID, workspace); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate thumbnail"})
+			return
+		}
+	}
+	if workspace.ThumbnailHash == thumbnailUnavailable {
+		respondThumbnailUnavailable(c)
+		return
+	}
+
+	// thumbnailUrl carries the hash as ?v=, so a matching request can be
+	// cached for good; anything else must revalidate against the ETag.
+	etag := fmt.Sprintf("%q", workspace.ThumbnailHash)
+	c.Header("ETag", etag)
+	if c.Query("v") == workspace.ThumbnailHash {
+		c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	} else {
+		c.Header("Cache-Control", "private, no-cache")
+	}
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	data, err := loadThumbnailBytes(c.Request.Context(), s.Blobs, workspace.ThumbnailHash)
+	if errors.Is(err, errBlobNotFound) {
+		// A thumbnail released by another workspace while this one was
+		// taking it up is rendered again.
+		if err = s.backfillWorkspaceThumbnail(c.Request.Context(), userID, workspace); err == nil {
+			if workspace.ThumbnailHash == thumbnailUnavailable {
+				respondThumbnailUnavailable(c)
+				return
+			}
+			c.Header("ETag", fmt.Sprintf("%q", workspace.ThumbnailHash))
+			data, err = loadThumbnailBytes(c.Request.Context(), s.Blobs, workspace.ThumbnailHash)
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load thumbnail"})
+		return
+	}
+
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, "image/png", data)
+}
+
+func respondThumbnailUnavailable(c *gin.Context) {
+	c.Header("Cache-Control", "private, no-cache")
+	c.JSON(http.StatusNotFound, gin.H{"error": "No thumbnail can be rendered for this workspace's image"})
+}
+
+func loadThumbnailBytes(ctx context.Context, blobs BlobStore, hash string) ([]byte, error) {
+	if blobs == nil {
+		return nil, fmt.Errorf("blob store not available")
+	}
+	return blobs.Get(ctx, thumbnailBlobKey(hash))
+}
+
+func workspaceThumbnailURL(workspace Workspace) string {
+	if workspace.ThumbnailHash == "" {
+		return fmt.Sprintf("/workspaces/%d/thumbnail", workspace.ID)
+	}
+	return fmt.Sprintf("/workspaces/%d/thumbnail?v=%s", workspace.ID, workspace.ThumbnailHash)
+}
+
+// storeThumbnail renders and stores a thumbnail, returning its hash. A
+// workspace without a thumbnail is still usable, so failures are logged
+// rather than failing the save. An image that cannot be rendered yields
+// thumbnailUnavailable; a blob store error yields "", so the thumbnail
+// endpoint tries again later.
+func storeThumbnail(ctx context.Context, blobs BlobStore, imageData []byte, state WorkspaceStateData) string {
+	if blobs == nil {
+		return ""
+	}
+
+	thumbnail, err := renderThumbnail(imageData, state, getEnvInt("THUMBNAIL_SIZE", defaultThumbnailSize))
+	if err != nil {
+		log.Printf("Failed to render thumbnail: %v", err)
+		return thumbnailUnavailable
+	}
+
+	hash := contentHash(thumbnail)
+	if err := blobs.Put(ctx, thumbnailBlobKey(hash), thumbnail, "image/png"); err != nil {
+		log.Printf("Failed to store thumbnail: %v", err)
+		return ""
+	}
+	return hash
+}
+
+// backfillWorkspaceThumbnail renders the thumbnail of a workspace loaded
+// without its image, which it fetches itself.
+func (s *Server) backfillWorkspaceThumbnail(ctx context.Context, userID uint, workspace *Workspace) error {
+	if s.Blobs == nil {
+		return fmt.Errorf("blob store not available")
+	}
+
+	full, err := s.Workspaces.Get(userID, fmt.Sprintf("%d", workspace.ID))
+	if err != nil {
+		return err
+	}
+	state, err := parseWorkspaceState(full.JsonData)
+	if err != nil {
+		return err
+	}
+
+	hash := thumbnailUnavailable
+	imageData, _, err := loadWorkspaceImageBytes(ctx, s.Blobs, *full)
+	switch {
+	case errors.Is(err, errInvalidImage):
+		// A legacy inline image of a type that is not rendered.
+	case err != nil:
+		return err
+	default:
+		hash = storeThumbnail(ctx, s.Blobs, imageData, state)
+		if hash == "" {
+			return fmt.Errorf("thumbnail generation failed")
+		}
+	}
+
+	if err := s.Workspaces.SetThumbnailHash(workspace.ID, hash); err != nil {
+		return err
+	}
+	workspace.ThumbnailHash = hash
+	return nil
+}
+
+// renderThumbnail scales the image to fit a size x size box and draws the
+// requested overlays: selector outlines on the image and a strip of the
+// palette colors below it.
+func renderThumbnail(imageData []byte, state WorkspaceStateData, size int) ([]byte, error) {
+	// The header alone gives the size, before anything is allocated for the
+	// pixels.
+	config, _, err := image.DecodeConfig(bytes.NewReader(imageData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if int64(config.Width)*int64(config.Height) > int64(getEnvInt("THUMBNAIL_MAX_PIXELS", defaultThumbnailMaxPixels)) {
+		return nil, fmt.Errorf("%w: %dx%d", errImageTooLarge, config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	options := defaultThumbnailOptions
+	if state.Thumbnail != nil {
+		options = *state.Thumbnail
+	}
+
+	bounds := src.Bounds()
+	scale := min(float64(size)/float64(bounds.Dx()), float64(size)/float64(bounds.Dy()), 1)
+	width := max(1, int(float64(bounds.Dx())*scale+0.5))
+	height := max(1, int(float64(bounds.Dy())*scale+0.5))
+
+	stripHeight := 0
+	if options.Palette && len(state.Colors) > 0 {
+		stripHeight = thumbnailStripHeight
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height+stripHeight))
+	xdraw.CatmullRom.Scale(dst, image.Rect(0, 0, width, height), src, bounds, xdraw.Src, nil)
+
+	if options.Selectors {
+		// Canvas coordinates -> original pixels -> thumbnail pixels.
+		canvasScale := min(float64(editorCanvasMaxWidth)/float64(bounds.Dx()),
+			float64(editorCanvasMaxHeight)/float64(bounds.Dy()), 1)
+		factor := scale / canvasScale
+
+		for _, selector := range state.Selectors {
+			if selector.Selection == nil {
+				continue
+			}
+			outline, err := hexToRGBA(selector.Color)
+			if err != nil {
+				outline = color.RGBA{255, 255, 255, 255}
+			}
+
+			s := selector.Selection
+			rect := image.Rect(
+				int(s.X*factor), int(s.Y*factor),
+				int((s.X+s.W)*factor), int((s.Y+s.H)*factor),
+			).Intersect(image.Rect(0, 0, width, height))
+			if rect.Empty() {
+				continue
+			}
+
+			strokeRect(dst, rect.Inset(-1), color.RGBA{0, 0, 0, 200})
+			strokeRect(dst, rect, outline)
+		}
+	}
+
+	if stripHeight > 0 {
+		for i, c := range state.Colors {
+			swatch, err := hexToRGBA(c.Hex)
+			if err != nil {
+				continue
+			}
+			x0 := i * width / len(state.Colors)
+			x1 := (i + 1) * width / len(state.Colors)
+			draw.Draw(dst, image.Rect(x0, height, x1, height+stripHeight), image.NewUniform(swatch), image.Point{}, draw.Src)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func strokeRect(dst *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	rect = rect.Intersect(dst.Bounds())
+	if rect.Empty() {
+		return
+	}
+	for x := rect.Min.X; x < rect.Max.X; x++ {
+		dst.SetRGBA(x, rect.Min.Y, c)
+		dst.SetRGBA(x, rect.Max.Y-1, c)
+	}
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		dst.SetRGBA(rect.Min.X, y, c)
+		dst.SetRGBA(rect.Max.X-1, y, c)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errTrashItemNotFound = errors.New("item not found in trash")
+
+const (
+	trashTypePalette   = "palette"
+	trashTypeWorkspace = "workspace"
+)
+
+// TrashItem is a deleted palette or workspace that can still be restored.
+// PurgeAt is omitted when TRASH_RETENTION_DAYS disables the purge.
+type TrashItem struct {
+	Type      string     `json:"type"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	DeletedAt time.Time  `json:"deletedAt"`
+	PurgeAt   *time.Time `json:"purgeAt,omitempty"`
+}
+
+type GetTrashResponse struct {
+	Items []TrashItem `json:"items"`
+}
+
+func trashRetention() time.Duration {
+	return time.Duration(getEnvInt("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour
+}
+
+func (s *Server) getTrashHandler(c *gin.Context) {
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to view the trash"})
+		return
+	}
+
+	items, err := s.getUserTrash(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, GetTrashResponse{Items: items})
+}
+
+func (s *Server) restoreTrashItemHandler(c *gin.Context) {
+	itemType := c.Param("type")
+	if itemType != trashTypePalette && itemType != trashTypeWorkspace {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be palette or workspace"})
+		return
+	}
+
+	itemID := c.Param("id")
+	if itemID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Item ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to restore items"})
+		return
+	}
+
+	if err := s.restoreUserTrashItem(userID, itemType, itemID); err != nil {
+		if errors.Is(err, errTrashItemNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore item"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item restored successfully"})
+}
+
+// getUserTrash lists deleted palettes and workspaces, most recently deleted
+// first.
+func (s *Server) getUserTrash(userID uint) ([]TrashItem, error) {
+	palettes, err := s.Palettes.ListDeleted(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces, err := s.Workspaces.ListDeleted(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]TrashItem, 0, len(palettes)+len(workspaces))
+	for _, palette := range palettes {
+		items = append(items, toTrashItem(trashTypePalette, palette.ID, palette.Name, palette.DeletedAt.Time))
+	}
+	for _, workspace := range workspaces {
+		items = append(items, toTrashItem(trashTypeWorkspace, workspace.ID, workspace.Name, workspace.DeletedAt.Time))
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+
+	return items, nil
+}
+
+func toTrashItem(itemType string, id uint, name string, deletedAt time.Time) TrashItem {
+	item := TrashItem{
+		Type:      itemType,
+		ID:        fmt.Sprintf("%d", id),
+		Name:      name,
+		DeletedAt: deletedAt,
+	}
+	if retention := trashRetention(); retention > 0 {
+		purgeAt := deletedAt.Add(retention)
+		item.PurgeAt = &purgeAt
+	}
+	return item
+}
+
+// The restored item keeps its updated_at, so it has the version editors
+// last saw.
+func (s *Server) restoreUserTrashItem(userID uint, itemType, itemID string) error {
+	if itemType == trashTypeWorkspace {
+		return s.Workspaces.Restore(userID, itemID)
+	}
+	return s.Palettes.Restore(userID, itemID)
+}
+
+// purgeExpiredTrash permanently deletes palettes and workspaces that have been
+// in the trash longer than TRASH_RETENTION_DAYS, along with the rows that
+// only exist for them and any blobs no other workspace uses.
+func purgeExpiredTrash() error {
+	if DB == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	retention := trashRetention()
+	if retention <= 0 {
+		return nil
+	}
+	cutoff := DB.NowFunc().Add(-retention)
+
+	var paletteIDs []uint
+	if err := DB.Unscoped().Model(&Palette{}).Where("deleted_at < ?", cutoff).Pluck("id", &paletteIDs).Error; err != nil {
+		return err
+	}
+	if len(paletteIDs) > 0 {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("palette_id IN ?", paletteIDs).Delete(&PaletteRevision{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("palette_id IN ?", paletteIDs).Delete(&PaletteLike{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM collection_palettes WHERE palette_id IN ?", paletteIDs).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("palette_id IN ?", paletteIDs).Delete(&PaletteColor{}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Where("id IN ?", paletteIDs).Delete(&Palette{}).Error
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("Purged %d palettes from the trash", len(paletteIDs))
+	}
+
+	var workspaces []Workspace
+	if err := DB.Unscoped().Select("id, image_hash, thumbnail_hash").
+		Where("deleted_at < ?", cutoff).
+		Find(&workspaces).Error; err != nil {
+		return err
+	}
+	if len(workspaces) > 0 {
+		workspaceIDs := make([]uint, len(workspaces))
+		for i, workspace := range workspaces {
+			workspaceIDs[i] = workspace.ID
+		}
+
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("DELETE FROM collection_workspaces WHERE workspace_id IN ?", workspaceIDs).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("workspace_id IN ?", workspaceIDs).Delete(&WorkspaceColor{}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Where("id IN ?", workspaceIDs).Delete(&Workspace{}).Error
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, workspace := range workspaces {
+			releaseWorkspaceBlobs(context.Background(), DB, Blobs, workspace.ImageHash, workspace.ThumbnailHash)
+		}
+		log.Printf("Purged %d workspaces from the trash", len(workspaces))
+	}
+
+	return nil
+}
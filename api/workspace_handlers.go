@@ -1,23 +1,35 @@
 package main
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+var (
+	errWorkspaceNotFound = errors.New("workspace not found or unauthorized")
+	errWorkspaceConflict = errors.New("workspace was modified by another request")
+)
+
+// Every column except the legacy inline image_data, for queries that only
+// need metadata.
+var workspaceSummaryColumns = []string{"id", "user_id", "name", "json_data", "tags", "image_hash", "image_type", "thumbnail_hash", "share_token", "share_expires_at", "share_password_hash", "share_views", "forked_from_id", "fork_count", "created_at", "updated_at"}
+
 type WorkspaceStateData struct {
-	Colors             []Color    `json:"colors"`
-	Selectors          []Selector `json:"selectors"`
-	ActiveSelectorId   string     `json:"activeSelectorId"`
-	Luminosity         float64    `json:"luminosity"`
-	Nearest            int        `json:"nearest"`
-	Power              int        `json:"power"`
-	MaxDistance        float64    `json:"maxDistance"`
+	Colors           []Color           `json:"colors"`
+	Selectors        []Selector        `json:"selectors"`
+	ActiveSelectorId string            `json:"activeSelectorId"`
+	Luminosity       float64           `json:"luminosity"`
+	Nearest          int               `json:"nearest"`
+	Power            int               `json:"power"`
+	MaxDistance      float64           `json:"maxDistance"`
+	Thumbnail        *ThumbnailOptions `json:"thumbnail,omitempty"`
 }
 
 type Selector struct {
@@ -35,43 +47,95 @@ type Selection struct {
 }
 
 type WorkspaceData struct {
-	ID                 string     `json:"id"`
-	Name               string     `json:"name"`
-	ImageData          string     `json:"imageData"`
-	Colors             []Color    `json:"colors"`
-	Selectors          []Selector `json:"selectors"`
-	ActiveSelectorId   string     `json:"activeSelectorId"`
-	Luminosity         float64    `json:"luminosity"`
-	Nearest            int        `json:"nearest"`
-	Power              int        `json:"power"`
-	MaxDistance        float64    `json:"maxDistance"`
-	ShareToken         *string    `json:"shareToken,omitempty"`
-	CreatedAt          string     `json:"createdAt"`
+	ID               string     `json:"id"`
+	Name             string     `json:"name"`
+	ImageData        string     `json:"imageData"`
+	Colors           []Color    `json:"colors"`
+	Selectors        []Selector `json:"selectors"`
+	ActiveSelectorId string     `json:"activeSelectorId"`
+	Luminosity       float64    `json:"luminosity"`
+	Nearest          int        `json:"nearest"`
+	Power            int        `json:"power"`
+	MaxDistance      float64    `json:"maxDistance"`
+	Tags             []string   `json:"tags"`
+	ShareToken       *string    `json:"shareToken,omitempty"`
+	ForkedFromID     *string    `json:"forkedFromId,omitempty"`
+	ForkCount        int        `json:"forkCount"`
+	ReadOnly         bool       `json:"readOnly,omitempty"`
+	CreatedAt        string     `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
 }
 
 type SaveWorkspaceRequest struct {
-	Name               string     `json:"name" binding:"required"`
-	ImageData          string     `json:"imageData" binding:"required"`
-	Colors             []Color    `json:"colors"`
-	Selectors          []Selector `json:"selectors"`
-	ActiveSelectorId   string     `json:"activeSelectorId"`
-	Luminosity         float64    `json:"luminosity"`
-	Nearest            int        `json:"nearest"`
-	Power              int        `json:"power"`
-	MaxDistance        float64    `json:"maxDistance"`
+	Name             string            `json:"name" binding:"required"`
+	ImageData        string            `json:"imageData" binding:"required"`
+	Colors           []Color           `json:"colors"`
+	Selectors        []Selector        `json:"selectors"`
+	ActiveSelectorId string            `json:"activeSelectorId"`
+	Luminosity       float64           `json:"luminosity"`
+	Nearest          int               `json:"nearest"`
+	Power            int               `json:"power"`
+	MaxDistance      float64           `json:"maxDistance"`
+	Tags             []string          `json:"tags"`
+	Thumbnail        *ThumbnailOptions `json:"thumbnail"`
+}
+
+// WorkspaceSummary is the list representation: everything needed to render a
+// workspace card without transferring the image itself.
+type WorkspaceSummary struct {
+	ID           string          `json:"id"`
+	Name         string          `json:"name"`
+	Colors       []Color         `json:"colors"`
+	Tags         []string        `json:"tags"`
+	ThumbnailURL string          `json:"thumbnailUrl"`
+	ShareToken   *string         `json:"shareToken,omitempty"`
+	Share        *WorkspaceShare `json:"share,omitempty"`
+	ForkedFromID *string         `json:"forkedFromId,omitempty"`
+	ForkCount    int             `json:"forkCount"`
+	CreatedAt    string          `json:"createdAt"`
+	UpdatedAt    time.Time       `json:"updatedAt"`
 }
 
 type GetWorkspacesResponse struct {
-	Workspaces []WorkspaceData `json:"workspaces"`
+	Workspaces []WorkspaceSummary `json:"workspaces"`
+	NextCursor string             `json:"nextCursor,omitempty"`
 }
 
-func saveWorkspaceHandler(c *gin.Context) {
+// UpdateWorkspaceRequest only touches the fields that are present, so the
+// client can save state without re-uploading the image. UpdatedAt (or an
+// If-Match header) is the version the client last saw.
+type UpdateWorkspaceRequest struct {
+	Name             *string           `json:"name"`
+	ImageData        *string           `json:"imageData"`
+	Colors           []Color           `json:"colors"`
+	Selectors        []Selector        `json:"selectors"`
+	ActiveSelectorId *string           `json:"activeSelectorId"`
+	Luminosity       *float64          `json:"luminosity"`
+	Nearest          *int              `json:"nearest"`
+	Power            *int              `json:"power"`
+	MaxDistance      *float64          `json:"maxDistance"`
+	Thumbnail        *ThumbnailOptions `json:"thumbnail"`
+	UpdatedAt        *time.Time        `json:"updatedAt"`
+}
+
+type UpdateWorkspaceTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+func (s *Server) saveWorkspaceHandler(c *gin.Context) {
 	var req SaveWorkspaceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Tags = tags
+
 	authenticated, userID := isAuthenticated(c)
 
 	if !authenticated {
@@ -79,19 +143,26 @@ func saveWorkspaceHandler(c *gin.Context) {
 		return
 	}
 
-	err := saveUserWorkspace(userID, req)
+	workspace, err := s.saveUserWorkspace(userID, req)
 	if err != nil {
+		if errors.Is(err, errInvalidImage) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save workspace"})
 		return
 	}
 
+	// The ETag lets the client save further changes with PUT right away.
+	c.Header("ETag", versionETag(workspace.UpdatedAt))
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Workspace saved successfully",
+		"id":      fmt.Sprintf("%d", workspace.ID),
 		"name":    req.Name,
 	})
 }
 
-func getWorkspacesHandler(c *gin.Context) {
+func (s *Server) getWorkspacesHandler(c *gin.Context) {
 	authenticated, userID := isAuthenticated(c)
 
 	if !authenticated {
@@ -99,16 +170,55 @@ func getWorkspacesHandler(c *gin.Context) {
 		return
 	}
 
-	workspaces, err := getUserWorkspaces(userID)
+	filter, err := parseListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := parsePageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspaces, nextCursor, err := s.getUserWorkspaces(userID, filter, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch workspaces"})
 		return
 	}
 
-	c.JSON(http.StatusOK, GetWorkspacesResponse{Workspaces: workspaces})
+	c.JSON(http.StatusOK, GetWorkspacesResponse{Workspaces: workspaces, NextCursor: nextCursor})
 }
 
-func deleteWorkspaceHandler(c *gin.Context) {
+func (s *Server) getWorkspaceHandler(c *gin.Context) {
+	workspaceID := c.Param("id")
+	if workspaceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to get workspaces"})
+		return
+	}
+
+	workspace, err := s.getUserWorkspace(userID, workspaceID)
+	if err != nil {
+		if errors.Is(err, errWorkspaceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch workspace"})
+		return
+	}
+
+	c.Header("ETag", versionETag(workspace.UpdatedAt))
+	c.JSON(http.StatusOK, workspace)
+}
+
+func (s *Server) deleteWorkspaceHandler(c *gin.Context) {
 	workspaceID := c.Param("id")
 	if workspaceID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace ID is required"})
@@ -122,8 +232,12 @@ func deleteWorkspaceHandler(c *gin.Context) {
 		return
 	}
 
-	err := deleteUserWorkspace(userID, workspaceID)
+	err := s.deleteUserWorkspace(userID, workspaceID)
 	if err != nil {
+		if errors.Is(err, errWorkspaceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -131,239 +245,372 @@ func deleteWorkspaceHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Workspace deleted successfully"})
 }
 
-func saveUserWorkspace(userID uint, req SaveWorkspaceRequest) error {
-	if DB == nil {
-		return fmt.Errorf("database not available")
+func (s *Server) updateWorkspaceHandler(c *gin.Context) {
+	workspaceID := c.Param("id")
+	if workspaceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace ID is required"})
+		return
 	}
 
-	stateData := WorkspaceStateData{
-		Colors:             req.Colors,
-		Selectors:          req.Selectors,
-		ActiveSelectorId:   req.ActiveSelectorId,
-		Luminosity:         req.Luminosity,
-		Nearest:            req.Nearest,
-		Power:              req.Power,
-		MaxDistance:        req.MaxDistance,
+	var req UpdateWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	stateJSON, err := json.Marshal(stateData)
-	if err != nil {
-		return err
+	if req.Name == nil && req.ImageData == nil && req.Colors == nil && req.Selectors == nil &&
+		req.ActiveSelectorId == nil && req.Luminosity == nil && req.Nearest == nil &&
+		req.Power == nil && req.MaxDistance == nil && req.Thumbnail == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+		return
+	}
+	if req.ImageData != nil && *req.ImageData == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image data cannot be empty"})
+		return
 	}
 
-	dbWorkspace := Workspace{
-		UserID:    &userID,
-		Name:      req.Name,
-		JsonData:  string(stateJSON),
-		ImageData: req.ImageData,
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to update workspaces"})
+		return
 	}
 
-	return DB.Create(&dbWorkspace).Error
+	workspace, err := s.updateUserWorkspace(userID, workspaceID, req, c.GetHeader("If-Match"))
+	if err != nil {
+		switch {
+		case errors.Is(err, errWorkspaceNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errInvalidImage):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errWorkspaceConflict) && c.GetHeader("If-Match") != "":
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		case errors.Is(err, errWorkspaceConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update workspace"})
+		}
+		return
+	}
+
+	c.Header("ETag", versionETag(workspace.UpdatedAt))
+	c.JSON(http.StatusOK, workspace)
 }
 
-func getUserWorkspaces(userID uint) ([]WorkspaceData, error) {
-	if DB == nil {
-		return nil, fmt.Errorf("database not available")
+func (s *Server) updateWorkspaceTagsHandler(c *gin.Context) {
+	workspaceID := c.Param("id")
+	if workspaceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace ID is required"})
+		return
 	}
 
-	var dbWorkspaces []Workspace
-	err := DB.Where("user_id = ?", userID).
-		Order("created_at DESC").
-		Find(&dbWorkspaces).Error
+	var req UpdateWorkspaceTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
+	tags, err := normalizeTags(req.Tags)
 	if err != nil {
-		return nil, err
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	workspaces := make([]WorkspaceData, len(dbWorkspaces))
-	for i, dbWorkspace := range dbWorkspaces {
-		var state WorkspaceStateData
-		if err := json.Unmarshal([]byte(dbWorkspace.JsonData), &state); err != nil {
-			continue
-		}
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to tag workspaces"})
+		return
+	}
 
-		workspaces[i] = WorkspaceData{
-			ID:                 fmt.Sprintf("%d", dbWorkspace.ID),
-			Name:               dbWorkspace.Name,
-			ImageData:          dbWorkspace.ImageData,
-			Colors:             state.Colors,
-			Selectors:          state.Selectors,
-			ActiveSelectorId:   state.ActiveSelectorId,
-			Luminosity:         state.Luminosity,
-			Nearest:            state.Nearest,
-			Power:              state.Power,
-			MaxDistance:        state.MaxDistance,
-			ShareToken:         dbWorkspace.ShareToken,
-			CreatedAt:          dbWorkspace.CreatedAt.Format("2006-01-02T15:04:05.000Z"),
+	if err := s.updateUserWorkspaceTags(userID, workspaceID, tags); err != nil {
+		switch {
+		case errors.Is(err, errWorkspaceNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errWorkspaceConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tags"})
 		}
+		return
 	}
 
-	return workspaces, nil
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
 }
 
-func deleteUserWorkspace(userID uint, workspaceID string) error {
-	if DB == nil {
-		return fmt.Errorf("database not available")
-	}
-
-	var workspace Workspace
-	if err := DB.Where("id = ? AND user_id = ?", workspaceID, userID).First(&workspace).Error; err != nil {
-		return fmt.Errorf("workspace not found or unauthorized")
+func (s *Server) saveUserWorkspace(userID uint, req SaveWorkspaceRequest) (*Workspace, error) {
+	stateData := WorkspaceStateData{
+		Colors:           req.Colors,
+		Selectors:        req.Selectors,
+		ActiveSelectorId: req.ActiveSelectorId,
+		Luminosity:       req.Luminosity,
+		Nearest:          req.Nearest,
+		Power:            req.Power,
+		MaxDistance:      req.MaxDistance,
+		Thumbnail:        req.Thumbnail,
 	}
 
-	result := DB.Delete(&workspace)
-	if result.Error != nil {
-		return result.Error
+	imageData, imageType, err := decodeDataURL(req.ImageData)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return s.createUserWorkspace(context.Background(), userID, req.Name, stateData, req.Tags, imageData, imageType)
 }
 
-func generateShareToken() (string, error) {
-	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
+// createUserWorkspace stores the image and its thumbnail and creates the
+// workspace row pointing at them.
+func (s *Server) createUserWorkspace(ctx context.Context, userID uint, name string, state WorkspaceStateData, tags []string, imageData []byte, imageType string) (*Workspace, error) {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
 	}
-	return hex.EncodeToString(bytes), nil
-}
 
-func shareWorkspaceHandler(c *gin.Context) {
-	workspaceID := c.Param("id")
-	if workspaceID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace ID is required"})
-		return
+	imageHash, err := storeImage(ctx, s.Blobs, imageData, imageType)
+	if err != nil {
+		return nil, err
 	}
 
-	authenticated, userID := isAuthenticated(c)
-	if !authenticated {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to share workspaces"})
-		return
+	dbWorkspace := Workspace{
+		UserID:        &userID,
+		Name:          name,
+		JsonData:      string(stateJSON),
+		ImageHash:     imageHash,
+		ImageType:     imageType,
+		ThumbnailHash: storeThumbnail(ctx, s.Blobs, imageData, state),
+		Tags:          encodeTags(tags),
 	}
 
-	shareToken, err := createWorkspaceShareToken(userID, workspaceID)
+	if err := s.Workspaces.Create(&dbWorkspace); err != nil {
+		return nil, err
+	}
+	// A concurrent releaseBlob may have deleted an identical image between
+	// storeImage and the insert; now that the row refers to it, store again.
+	if _, err := storeImage(ctx, s.Blobs, imageData, imageType); err != nil {
+		return nil, err
+	}
+	return &dbWorkspace, nil
+}
+
+func (s *Server) getUserWorkspaces(userID uint, filter ListFilter, page PageParams) ([]WorkspaceSummary, string, error) {
+	dbWorkspaces, nextCursor, err := s.Workspaces.List(userID, filter, page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, "", err
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"shareToken": shareToken,
-		"shareUrl":   fmt.Sprintf("http://localhost:5173/shared/%s", shareToken),
-	})
-}
+	workspaces := make([]WorkspaceSummary, 0, len(dbWorkspaces))
+	for _, dbWorkspace := range dbWorkspaces {
+		var state WorkspaceStateData
+		json.Unmarshal([]byte(dbWorkspace.JsonData), &state)
 
-func getSharedWorkspaceHandler(c *gin.Context) {
-	shareToken := c.Param("token")
-	if shareToken == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Share token is required"})
-		return
+		workspaces = append(workspaces, toWorkspaceSummary(dbWorkspace, state))
 	}
 
-	workspace, err := getWorkspaceByShareToken(shareToken)
+	return workspaces, nextCursor, nil
+}
+
+func (s *Server) getUserWorkspace(userID uint, workspaceID string) (*WorkspaceData, error) {
+	dbWorkspace, err := s.Workspaces.Get(userID, workspaceID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Shared workspace not found"})
-		return
+		return nil, err
 	}
 
-	c.JSON(http.StatusOK, workspace)
+	return s.toWorkspaceData(*dbWorkspace)
 }
 
-func removeWorkspaceShareHandler(c *gin.Context) {
-	workspaceID := c.Param("id")
-	if workspaceID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace ID is required"})
-		return
+func toWorkspaceSummary(dbWorkspace Workspace, state WorkspaceStateData) WorkspaceSummary {
+	return WorkspaceSummary{
+		ID:           fmt.Sprintf("%d", dbWorkspace.ID),
+		Name:         dbWorkspace.Name,
+		Colors:       state.Colors,
+		Tags:         decodeTags(dbWorkspace.Tags),
+		ThumbnailURL: workspaceThumbnailURL(dbWorkspace),
+		ShareToken:   dbWorkspace.ShareToken,
+		Share:        toWorkspaceShare(dbWorkspace),
+		ForkedFromID: workspaceForkedFromID(dbWorkspace),
+		ForkCount:    dbWorkspace.ForkCount,
+		CreatedAt:    dbWorkspace.CreatedAt.Format("2006-01-02T15:04:05.000Z"),
+		UpdatedAt:    dbWorkspace.UpdatedAt,
 	}
+}
 
-	authenticated, userID := isAuthenticated(c)
-	if !authenticated {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
-		return
+func workspaceForkedFromID(dbWorkspace Workspace) *string {
+	if dbWorkspace.ForkedFromID == nil {
+		return nil
 	}
+	forkedFrom := fmt.Sprintf("%d", *dbWorkspace.ForkedFromID)
+	return &forkedFrom
+}
 
-	err := removeWorkspaceShareToken(userID, workspaceID)
+func parseWorkspaceState(data string) (WorkspaceStateData, error) {
+	var state WorkspaceStateData
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return state, fmt.Errorf("failed to parse workspace data")
+	}
+	return state, nil
+}
+
+func (s *Server) toWorkspaceData(dbWorkspace Workspace) (*WorkspaceData, error) {
+	state, err := parseWorkspaceState(dbWorkspace.JsonData)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Share link removed successfully"})
+	imageData, err := loadWorkspaceImage(context.Background(), s.Blobs, dbWorkspace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workspace image: %w", err)
+	}
+
+	return &WorkspaceData{
+		ID:               fmt.Sprintf("%d", dbWorkspace.ID),
+		Name:             dbWorkspace.Name,
+		ImageData:        imageData,
+		Colors:           state.Colors,
+		Selectors:        state.Selectors,
+		ActiveSelectorId: state.ActiveSelectorId,
+		Luminosity:       state.Luminosity,
+		Nearest:          state.Nearest,
+		Power:            state.Power,
+		MaxDistance:      state.MaxDistance,
+		Tags:             decodeTags(dbWorkspace.Tags),
+		ShareToken:       dbWorkspace.ShareToken,
+		ForkedFromID:     workspaceForkedFromID(dbWorkspace),
+		ForkCount:        dbWorkspace.ForkCount,
+		CreatedAt:        dbWorkspace.CreatedAt.Format("2006-01-02T15:04:05.000Z"),
+		UpdatedAt:        dbWorkspace.UpdatedAt,
+	}, nil
 }
 
-func createWorkspaceShareToken(userID uint, workspaceID string) (string, error) {
-	if DB == nil {
-		return "", fmt.Errorf("database not available")
+// The image column is neither loaded nor written unless a new image is
+// supplied, and the update only applies if updated_at is still the value read
+// here, so two tabs saving the same workspace cannot silently overwrite each
+// other.
+func (s *Server) updateUserWorkspace(userID uint, workspaceID string, req UpdateWorkspaceRequest, ifMatch string) (*WorkspaceSummary, error) {
+	workspace, err := s.Workspaces.GetSummary(userID, workspaceID)
+	if err != nil {
+		return nil, err
 	}
 
-	var workspace Workspace
-	if err := DB.Where("id = ? AND user_id = ?", workspaceID, userID).First(&workspace).Error; err != nil {
-		return "", fmt.Errorf("workspace not found or unauthorized")
+	if ifMatch != "" && ifMatch != "*" && ifMatch != versionETag(workspace.UpdatedAt) {
+		return nil, errWorkspaceConflict
+	}
+	if req.UpdatedAt != nil && req.UpdatedAt.UnixMicro() != workspace.UpdatedAt.UnixMicro() {
+		return nil, errWorkspaceConflict
 	}
 
-	if workspace.ShareToken != nil {
-		return *workspace.ShareToken, nil
+	state, err := parseWorkspaceState(workspace.JsonData)
+	if err != nil {
+		return nil, err
+	}
+	if req.Colors != nil {
+		state.Colors = req.Colors
+	}
+	if req.Selectors != nil {
+		state.Selectors = req.Selectors
+	}
+	if req.ActiveSelectorId != nil {
+		state.ActiveSelectorId = *req.ActiveSelectorId
+	}
+	if req.Luminosity != nil {
+		state.Luminosity = *req.Luminosity
+	}
+	if req.Nearest != nil {
+		state.Nearest = *req.Nearest
+	}
+	if req.Power != nil {
+		state.Power = *req.Power
+	}
+	if req.MaxDistance != nil {
+		state.MaxDistance = *req.MaxDistance
+	}
+	if req.Thumbnail != nil {
+		state.Thumbnail = req.Thumbnail
 	}
 
-	shareToken, err := generateShareToken()
+	stateJSON, err := json.Marshal(state)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate share token")
+		return nil, err
 	}
 
-	workspace.ShareToken = &shareToken
-	if err := DB.Save(&workspace).Error; err != nil {
-		return "", fmt.Errorf("failed to save share token")
+	updates := map[string]any{
+		"json_data": string(stateJSON),
+	}
+	if req.Name != nil {
+		updates["name"] = strings.TrimSpace(*req.Name)
 	}
 
-	return shareToken, nil
-}
+	ctx := context.Background()
+	var imageData []byte
+	var imageType string
+	if req.ImageData != nil {
+		imageData, imageType, err = decodeDataURL(*req.ImageData)
+		if err != nil {
+			return nil, err
+		}
+		imageHash, err := storeImage(ctx, s.Blobs, imageData, imageType)
+		if err != nil {
+			return nil, err
+		}
+		updates["image_hash"] = imageHash
+		updates["image_type"] = imageType
+		updates["image_data"] = ""
+	}
+
+	// The thumbnail shows the image, selectors and colors, so any of those
+	// changing means rendering a new one.
+	// If the image cannot be read here the hash is cleared, and the
+	// thumbnail endpoint renders a fresh one on the next request.
+	if req.ImageData != nil || req.Colors != nil || req.Selectors != nil || req.Thumbnail != nil {
+		updates["thumbnail_hash"] = ""
+		if imageData == nil {
+			imageData, _, _ = loadWorkspaceImageBytes(ctx, s.Blobs, *workspace)
+		}
+		if imageData != nil {
+			updates["thumbnail_hash"] = storeThumbnail(ctx, s.Blobs, imageData, state)
+		}
+	}
 
-func getWorkspaceByShareToken(shareToken string) (*WorkspaceData, error) {
-	if DB == nil {
-		return nil, fmt.Errorf("database not available")
+	previous := *workspace
+	if err := s.Workspaces.Update(workspace, updates); err != nil {
+		return nil, err
+	}
+	// As in createUserWorkspace, the image may have been released between
+	// storeImage and the update.
+	if req.ImageData != nil {
+		if _, err := storeImage(ctx, s.Blobs, imageData, imageType); err != nil {
+			return nil, err
+		}
 	}
 
-	var dbWorkspace Workspace
-	if err := DB.Where("share_token = ?", shareToken).First(&dbWorkspace).Error; err != nil {
-		return nil, fmt.Errorf("workspace not found")
+	var releasedImage, releasedThumbnail string
+	if previous.ImageHash != workspace.ImageHash {
+		releasedImage = previous.ImageHash
+	}
+	if previous.ThumbnailHash != workspace.ThumbnailHash {
+		releasedThumbnail = previous.ThumbnailHash
 	}
+	s.Workspaces.ReleaseBlobs(ctx, releasedImage, releasedThumbnail)
 
-	var state WorkspaceStateData
-	if err := json.Unmarshal([]byte(dbWorkspace.JsonData), &state); err != nil {
-		return nil, fmt.Errorf("failed to parse workspace data")
-	}
-
-	workspace := &WorkspaceData{
-		ID:                 fmt.Sprintf("%d", dbWorkspace.ID),
-		Name:               dbWorkspace.Name,
-		ImageData:          dbWorkspace.ImageData,
-		Colors:             state.Colors,
-		Selectors:          state.Selectors,
-		ActiveSelectorId:   state.ActiveSelectorId,
-		Luminosity:         state.Luminosity,
-		Nearest:            state.Nearest,
-		Power:              state.Power,
-		MaxDistance:        state.MaxDistance,
-		ShareToken:         dbWorkspace.ShareToken,
-		CreatedAt:          dbWorkspace.CreatedAt.Format("2006-01-02T15:04:05.000Z"),
-	}
-
-	return workspace, nil
+	summary := toWorkspaceSummary(*workspace, state)
+	return &summary, nil
 }
 
-func removeWorkspaceShareToken(userID uint, workspaceID string) error {
-	if DB == nil {
-		return fmt.Errorf("database not available")
+func (s *Server) deleteUserWorkspace(userID uint, workspaceID string) error {
+	workspace, err := s.Workspaces.Get(userID, workspaceID)
+	if err != nil {
+		return err
 	}
 
-	var workspace Workspace
-	if err := DB.Where("id = ? AND user_id = ?", workspaceID, userID).First(&workspace).Error; err != nil {
-		return fmt.Errorf("workspace not found or unauthorized")
-	}
+	return s.Workspaces.Delete(workspace)
+}
 
-	workspace.ShareToken = nil
-	if err := DB.Save(&workspace).Error; err != nil {
-		return fmt.Errorf("failed to remove share token")
+func (s *Server) updateUserWorkspaceTags(userID uint, workspaceID string, tags []string) error {
+	workspace, err := s.Workspaces.GetSummary(userID, workspaceID)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return s.Workspaces.Update(workspace, map[string]any{"tags": encodeTags(tags)})
 }
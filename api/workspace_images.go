@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (s *Server) getWorkspaceImageHandler(c *gin.Context) {
+	workspaceID := c.Param("id")
+	if workspaceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace ID is required"})
+		return
+	}
+
+	authenticated, userID := isAuthenticated(c)
+	if !authenticated {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required to get workspaces"})
+		return
+	}
+
+	workspace, err := s.Workspaces.GetSummary(userID, workspaceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errWorkspaceNotFound.Error()})
+		return
+	}
+
+	// The hash changes whenever the image does, so it makes a stable ETag.
+	etag := fmt.Sprintf("%q", workspace.ImageHash)
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("ETag", etag)
+	if workspace.ImageHash != "" && c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	data, contentType, err := loadWorkspaceImageBytes(c.Request.Context(), s.Blobs, *workspace)
+	if err != nil {
+		// Only legacy inline images can fail to decode; see brokenImageType.
+		if errors.Is(err, errInvalidImage) {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "The stored image is not a PNG, JPEG, GIF or WebP image and cannot be shown"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load workspace image"})
+		return
+	}
+
+	// Images stored before types were checked may carry whatever the client
+	// claimed, so the type is sniffed again and browsers may not guess.
+	if contentType, err = detectImageType(data); err != nil {
+		contentType = "application/octet-stream"
+	}
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// storeImage writes an image to the blob store and returns its content hash.
+// Identical images map to the same object.
+func storeImage(ctx context.Context, blobs BlobStore, data []byte, contentType string) (string, error) {
+	if blobs == nil {
+		return "", fmt.Errorf("blob store not available")
+	}
+
+	hash := contentHash(data)
+	key := imageBlobKey(hash)
+
+	exists, err := blobs.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		if err := blobs.Put(ctx, key, data, contentType); err != nil {
+			return "", err
+		}
+	}
+
+	return hash, nil
+}
+
+// loadWorkspaceImageBytes returns the raw image. Rows not yet moved by
+// migrateWorkspaceImages still carry it inline as a data URL.
+func loadWorkspaceImageBytes(ctx context.Context, blobs BlobStore, workspace Workspace) ([]byte, string, error) {
+	if workspace.ImageHash == "" {
+		return decodeDataURL(workspace.ImageData)
+	}
+	if blobs == nil {
+		return nil, "", fmt.Errorf("blob store not available")
+	}
+
+	data, err := blobs.Get(ctx, imageBlobKey(workspace.ImageHash))
+	if err != nil {
+		return nil, "", err
+	}
+	return data, workspace.ImageType, nil
+}
+
+// loadWorkspaceImage returns the image as the data URL the API exposes.
+func loadWorkspaceImage(ctx context.Context, blobs BlobStore, workspace Workspace) (string, error) {
+	if workspace.ImageHash == "" {
+		return workspace.ImageData, nil
+	}
+
+	data, contentType, err := loadWorkspaceImageBytes(ctx, blobs, workspace)
+	if err != nil {
+		return "", err
+	}
+	return encodeDataURL(data, contentType), nil
+}
+
+// releaseWorkspaceBlobs deletes the image and thumbnail blobs of a workspace
+// that no other workspace refers to any more.
+func releaseWorkspaceBlobs(ctx context.Context, db *gorm.DB, blobs BlobStore, imageHash, thumbnailHash string) {
+	releaseBlob(ctx, db, blobs, "image_hash", imageHash, imageBlobKey)
+	releaseBlob(ctx, db, blobs, "thumbnail_hash", thumbnailHash, thumbnailBlobKey)
+}
+
+// releaseBlob deletes a blob once no workspace refers to it through column.
+// Workspaces in the trash still count, so they can be restored intact.
+// Failures are only logged: an orphaned blob wastes space but breaks nothing.
+//
+// The count and the delete happen under the blob's lock, which every write of
+// a row referring to the blob also takes, so no reference can appear in
+// between. A writer whose storeImage ran before the delete stores the image
+// again once its row is committed.
+func releaseBlob(ctx context.Context, db *gorm.DB, blobs BlobStore, column, hash string, key func(string) string) {
+	if hash == "" || blobs == nil || db == nil {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockBlobs(tx, key(hash)); err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Unscoped().Model(&Workspace{}).Where(column+" = ?", hash).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check references: %w", err)
+		}
+		if count > 0 {
+			return nil
+		}
+		return blobs.Delete(ctx, key(hash))
+	})
+	if err != nil {
+		log.Printf("Failed to release blob %s: %v", key(hash), err)
+	}
+}
+
+// blobLockClass is an arbitrary first key for the two-key advisory locks on
+// blobs. Two-key locks never collide with single-key ones like migrations'.
+const blobLockClass = 72184360
+
+// lockBlobs holds the given blob keys until tx ends. Postgres takes an
+// advisory lock per key, in sorted order so two callers cannot deadlock.
+// SQLite needs nothing: its immediate transactions hold the database write
+// lock from the start.
+func lockBlobs(tx *gorm.DB, keys ...string) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	keys = slices.Clone(keys)
+	slices.Sort(keys)
+	for _, key := range slices.Compact(keys) {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", blobLockClass, key).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// workspaceBlobKeys lists the blobs a workspace row refers to.
+func workspaceBlobKeys(workspace *Workspace) []string {
+	var keys []string
+	if workspace.ImageHash != "" {
+		keys = append(keys, imageBlobKey(workspace.ImageHash))
+	}
+	if workspace.ThumbnailHash != "" {
+		keys = append(keys, thumbnailBlobKey(workspace.ThumbnailHash))
+	}
+	return keys
+}
+
+// brokenImageType marks legacy rows whose inline image is not one of the
+// allowed types. They keep their image_data, but migrateWorkspaceImages
+// leaves them alone from then on instead of skipping them on every start.
+const brokenImageType = "invalid"
+
+// migrateWorkspaceImages moves images still stored inline in image_data into
+// the blob store. It runs at startup, works in batches and is safe to
+// interrupt: each row is switched over only after its blob has been written.
+func migrateWorkspaceImages() error {
+	if DB == nil || Blobs == nil {
+		return fmt.Errorf("database or blob store not available")
+	}
+
+	ctx := context.Background()
+	migrated := 0
+
+	var batch []Workspace
+	// Trashed workspaces are migrated too, so restoring one finds its image.
+	result := DB.Unscoped().Where("image_hash = '' AND image_data <> '' AND image_type <> ?", brokenImageType).
+		FindInBatches(&batch, 20, func(tx *gorm.DB, _ int) error {
+			for _, workspace := range batch {
+				data, contentType, err := decodeDataURL(workspace.ImageData)
+				if errors.Is(err, errInvalidImage) {
+					log.Printf("Marking workspace %d: stored image is not a PNG, JPEG, GIF or WebP data URL", workspace.ID)
+					err := DB.Unscoped().Model(&Workspace{}).Where("id = ?", workspace.ID).
+						UpdateColumn("image_type", brokenImageType).Error
+					if err != nil {
+						return err
+					}
+					continue
+				}
+
+				hash, err := storeImage(ctx, Blobs, data, contentType)
+				if err != nil {
+					return err
+				}
+
+				err = DB.Transaction(func(tx *gorm.DB) error {
+					if err := lockBlobs(tx, imageBlobKey(hash)); err != nil {
+						return err
+					}
+					return tx.Unscoped().Model(&Workspace{}).Where("id = ?", workspace.ID).UpdateColumns(map[string]any{
+						"image_hash": hash,
+						"image_type": contentType,
+						"image_data": "",
+					}).Error
+				})
+				if err != nil {
+					return err
+				}
+				// See releaseBlob: the blob may have gone before the row
+				// referred to it.
+				if _, err := storeImage(ctx, Blobs, data, contentType); err != nil {
+					return err
+				}
+				migrated++
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if migrated > 0 {
+		log.Printf("Moved %d workspace images to the blob store", migrated)
+	}
+	return nil
+}
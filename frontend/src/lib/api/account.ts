@@ -0,0 +1,65 @@
+import { getAuthHeaders, getAuthToken } from './auth';
+import { buildURL, ensureOk } from './base';
+
+export type ImportDuplicates = 'skip' | 'keep';
+
+export interface ImportItemResult {
+	sourceId: string;
+	name: string;
+	status: 'imported' | 'skipped' | 'failed';
+	id?: string;
+	importedAs?: string;
+	duplicate: boolean;
+	duplicateOf?: string;
+	error?: string;
+}
+
+export interface ImportAccountResult {
+	palettes: ImportItemResult[];
+	workspaces: ImportItemResult[];
+	imported: number;
+	skipped: number;
+	failed: number;
+}
+
+function exportFilename(response: Response): string {
+	const disposition = response.headers.get('Content-Disposition') ?? '';
+	const match = /filename="([^"]+)"/.exec(disposition);
+	return match ? match[1] : 'image-to-palette-export.zip';
+}
+
+// Downloads a zip of the user's profile, palettes and workspaces.
+export async function exportAccount(): Promise<void> {
+	const response = await fetch(buildURL('/account/export'), {
+		method: 'GET',
+		headers: getAuthHeaders()
+	});
+
+	await ensureOk(response);
+	const blob = await response.blob();
+
+	const url = URL.createObjectURL(blob);
+	const link = document.createElement('a');
+	link.href = url;
+	link.download = exportFilename(response);
+	link.click();
+	URL.revokeObjectURL(url);
+}
+
+// Recreates the palettes and workspaces of an exported archive under the
+// current user. Duplicates are skipped unless `duplicates` is 'keep'.
+export async function importAccount(file: File, duplicates: ImportDuplicates = 'skip'): Promise<ImportAccountResult> {
+	const formData = new FormData();
+	formData.append('file', file, file.name);
+	formData.append('duplicates', duplicates);
+
+	const token = getAuthToken();
+	const response = await fetch(buildURL('/account/import'), {
+		method: 'POST',
+		headers: token ? { Authorization: `Bearer ${token}` } : {},
+		body: formData
+	});
+
+	await ensureOk(response);
+	return response.json();
+}
@@ -1,4 +1,10 @@
-import type { Color, SavePaletteRequest, GetPalettesResponse, SavePaletteResult } from '$lib/types/palette';
+import type {
+	Color,
+	SavePaletteRequest,
+	GetPalettesResponse,
+	SavePaletteResult,
+	PaletteShare
+} from '$lib/types/palette';
 
 import { getAuthHeaders } from './auth';
 import { buildURL, buildZigURL, ensureOk, ZIG_API_BASE } from './base';
@@ -22,6 +28,18 @@ export type ZigPaletteResponse = {
 	palette: Color[];
 };
 
+// Fills in the nearest known name for colors that do not have one.
+export async function nameColors(palette: Color[]): Promise<Color[]> {
+	const res = await fetch(buildURL('/palettes/names'), {
+		method: 'POST',
+		headers: { 'Content-Type': 'application/json' },
+		body: JSON.stringify({ palette })
+	});
+	await ensureOk(res);
+	const data: { palette: Color[] } = await res.json();
+	return data.palette;
+}
+
 export async function extractPalette(file: Blob | File): Promise<ZigPaletteResponse> {
 	if (!file) throw new Error('No files provided');
 
@@ -109,19 +127,45 @@ export async function savePalette(name: string, colors: Color[]): Promise<SavePa
 }
 
 export async function getPalettes(): Promise<GetPalettesResponse> {
-	const res = await fetch(buildURL('/palettes'), {
-		method: 'GET',
+	const palettes: GetPalettesResponse['palettes'] = [];
+	let cursor: string | null = null;
+
+	do {
+		const res = await fetch(buildURL('/palettes', { cursor }), {
+			method: 'GET',
+			headers: getAuthHeaders()
+		});
+		await ensureOk(res);
+		const page: GetPalettesResponse = await res.json();
+		palettes.push(...page.palettes);
+		cursor = page.nextCursor ?? null;
+	} while (cursor);
+
+	return { palettes };
+}
+
+export async function deletePalette(id: string): Promise<{ message: string }> {
+	const res = await fetch(buildURL(`/palettes/${id}`), {
+		method: 'DELETE',
 		headers: getAuthHeaders()
 	});
 	await ensureOk(res);
 	return res.json();
 }
 
-export async function deletePalette(id: string): Promise<{ message: string }> {
-	const res = await fetch(buildURL(`/palettes/${id}`), {
-		method: 'DELETE',
+export async function sharePalette(id: string): Promise<PaletteShare> {
+	const res = await fetch(buildURL(`/palettes/${id}/share`), {
+		method: 'POST',
 		headers: getAuthHeaders()
 	});
 	await ensureOk(res);
 	return res.json();
 }
+
+export async function removePaletteShare(id: string): Promise<void> {
+	const res = await fetch(buildURL(`/palettes/${id}/share`), {
+		method: 'DELETE',
+		headers: getAuthHeaders()
+	});
+	await ensureOk(res);
+}
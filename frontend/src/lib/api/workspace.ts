@@ -3,7 +3,9 @@ import type {
 	SaveWorkspaceRequest,
 	GetWorkspacesResponse,
 	SaveWorkspaceResult,
+	ShareWorkspaceOptions,
 	ShareWorkspaceResult,
+	VersionedWorkspace,
 	WorkspaceData
 } from '$lib/types/palette';
 
@@ -25,17 +27,68 @@ export async function saveWorkspace(
 	});
 
 	await ensureOk(response);
-	return response.json();
+	return { ...(await response.json()), etag: response.headers.get('ETag') };
 }
 
 export async function getWorkspaces(): Promise<GetWorkspacesResponse> {
-	const response = await fetch(buildURL('/workspaces'), {
+	const workspaces: GetWorkspacesResponse['workspaces'] = [];
+	let cursor: string | null = null;
+
+	do {
+		const response = await fetch(buildURL('/workspaces', { cursor }), {
+			method: 'GET',
+			headers: getAuthHeaders()
+		});
+
+		await ensureOk(response);
+		const page: GetWorkspacesResponse = await response.json();
+		workspaces.push(...page.workspaces);
+		cursor = page.nextCursor ?? null;
+	} while (cursor);
+
+	return { workspaces };
+}
+
+export async function getWorkspace(workspaceId: string): Promise<VersionedWorkspace> {
+	const response = await fetch(buildURL(`/workspaces/${workspaceId}`), {
 		method: 'GET',
 		headers: getAuthHeaders()
 	});
 
 	await ensureOk(response);
-	return response.json();
+	return { workspace: await response.json(), etag: response.headers.get('ETag') };
+}
+
+// Thumbnails need the auth header, so they are fetched here and handed to
+// <img> as an object URL.
+export async function getWorkspaceThumbnail(thumbnailUrl: string): Promise<string> {
+	const response = await fetch(buildURL(thumbnailUrl), {
+		method: 'GET',
+		headers: getAuthHeaders()
+	});
+
+	await ensureOk(response);
+	return URL.createObjectURL(await response.blob());
+}
+
+// Only the fields in changes are written. With an etag the server refuses
+// the update (412) if the workspace changed since that version was read.
+export async function updateWorkspace(
+	workspaceId: string,
+	changes: Partial<SaveWorkspaceRequest> & { updatedAt?: string },
+	etag?: string | null
+): Promise<VersionedWorkspace> {
+	const headers = getAuthHeaders();
+	if (etag) headers['If-Match'] = etag;
+
+	const response = await fetch(buildURL(`/workspaces/${workspaceId}`), {
+		method: 'PUT',
+		headers,
+		body: JSON.stringify(changes)
+	});
+
+	await ensureOk(response);
+	return { workspace: await response.json(), etag: response.headers.get('ETag') };
 }
 
 export async function deleteWorkspace(workspaceId: string): Promise<void> {
@@ -47,10 +100,14 @@ export async function deleteWorkspace(workspaceId: string): Promise<void> {
 	await ensureOk(response);
 }
 
-export async function shareWorkspace(workspaceId: string): Promise<ShareWorkspaceResult> {
+export async function shareWorkspace(
+	workspaceId: string,
+	options: ShareWorkspaceOptions = {}
+): Promise<ShareWorkspaceResult> {
 	const response = await fetch(buildURL(`/workspaces/${workspaceId}/share`), {
 		method: 'POST',
-		headers: getAuthHeaders()
+		headers: getAuthHeaders(),
+		body: JSON.stringify(options)
 	});
 
 	await ensureOk(response);
@@ -66,11 +123,39 @@ export async function removeWorkspaceShare(workspaceId: string): Promise<void> {
 	await ensureOk(response);
 }
 
-export async function getSharedWorkspace(shareToken: string): Promise<WorkspaceData> {
-	const response = await fetch(buildURL('/shared', { token: shareToken }), {
-		method: 'GET'
+export class SharePasswordRequiredError extends Error {
+	constructor(message: string) {
+		super(message);
+		this.name = 'SharePasswordRequiredError';
+	}
+}
+
+export async function forkSharedWorkspace(shareToken: string, password?: string): Promise<WorkspaceData> {
+	const headers = getAuthHeaders();
+	if (password) headers['X-Share-Password'] = password;
+
+	const response = await fetch(buildURL(`/shared/${encodeURIComponent(shareToken)}/fork`), {
+		method: 'POST',
+		headers
+	});
+
+	await ensureOk(response);
+	return response.json();
+}
+
+export async function getSharedWorkspace(shareToken: string, password?: string): Promise<WorkspaceData> {
+	const response = await fetch(buildURL(`/shared/${encodeURIComponent(shareToken)}`), {
+		method: 'GET',
+		headers: password ? { 'X-Share-Password': password } : {}
 	});
 
+	if (response.status === 401) {
+		const data = await response.json().catch(() => null);
+		if (data?.passwordRequired) {
+			throw new SharePasswordRequiredError(data.error ?? 'This share link is password protected');
+		}
+	}
+
 	await ensureOk(response);
 	return response.json();
 }
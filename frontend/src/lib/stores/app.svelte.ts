@@ -41,6 +41,8 @@ interface AppState {
 	newFilterColor: string;
 	savedPalettes: PaletteData[];
 	savedWorkspaces: WorkspaceData[];
+	// The saved workspace on the canvas, which saving updates in place.
+	currentWorkspace: { id: string; name: string; etag: string | null } | null;
 	sortMethod: SortMethod;
 
 	luminosity: number;
@@ -82,6 +84,7 @@ function createAppStore() {
 		newFilterColor: '',
 		savedPalettes: [],
 		savedWorkspaces: [],
+		currentWorkspace: null,
 		sortMethod: 'none',
 
 		luminosity: 1,
@@ -220,6 +223,7 @@ function createAppStore() {
 
 					state.canvasContext.drawImage(state.image, 0, 0, dimensions.width, dimensions.height);
 					state.imageLoaded = true;
+					state.currentWorkspace = null;
 					drawImageAndBoxes();
 				};
 				state.image.src = reader.result as string;
@@ -257,6 +261,7 @@ function createAppStore() {
 						state.canvasContext.drawImage(newImage, 0, 0, dimensions.width, dimensions.height);
 						state.image = newImage;
 						state.imageLoaded = true;
+						state.currentWorkspace = null;
 						drawImageAndBoxes();
 						URL.revokeObjectURL(url);
 						resolve();
@@ -477,7 +482,8 @@ function createAppStore() {
 			try {
 				const result = await api.extractPalette(file);
 				if (result.palette.length > 0) {
-					state.colors = result.palette;
+					// Names are a nicety; keep the bare colors if the API is unreachable.
+					state.colors = await api.nameColors(result.palette).catch(() => result.palette);
 					toast.success('Palette extracted', { id: toastId });
 				} else {
 					toast.error('No colors found in selected regions', { id: toastId });
@@ -709,7 +715,8 @@ function createAppStore() {
 				return;
 			}
 
-			const workspaceName = prompt('Enter a name for your workspace:');
+			const current = state.currentWorkspace;
+			const workspaceName = prompt('Enter a name for your workspace:', current?.name ?? '');
 			if (!workspaceName) return;
 
 			const toastId = toast.loading('Saving workspace...');
@@ -722,7 +729,7 @@ function createAppStore() {
 				}
 
 				if (authStore.state.isAuthenticated && !authStore.isDemoUser()) {
-					await workspaceApi.saveWorkspace(workspaceName, imageDataUrl, {
+					const workspaceState = {
 						colors: state.colors,
 						selectors: state.selectors,
 						activeSelectorId: state.activeSelectorId,
@@ -730,7 +737,21 @@ function createAppStore() {
 						nearest: state.nearest,
 						power: state.power,
 						maxDistance: state.maxDistance
-					});
+					};
+
+					// The image is unchanged since the workspace was loaded, so an
+					// update only sends the state.
+					if (current) {
+						const { workspace, etag } = await workspaceApi.updateWorkspace(
+							current.id,
+							{ name: workspaceName, ...workspaceState },
+							current.etag
+						);
+						state.currentWorkspace = { id: workspace.id, name: workspace.name, etag };
+					} else {
+						const result = await workspaceApi.saveWorkspace(workspaceName, imageDataUrl, workspaceState);
+						state.currentWorkspace = { id: result.id, name: workspaceName, etag: result.etag };
+					}
 					toast.success('Workspace saved: ' + workspaceName, { id: toastId });
 					await appStore.loadSavedWorkspaces();
 				} else {
@@ -767,6 +788,11 @@ function createAppStore() {
 			const toastId = toast.loading('Loading workspace...');
 
 			try {
+				let etag: string | null = null;
+				if (!workspace.imageData) {
+					({ workspace, etag } = await workspaceApi.getWorkspace(workspace.id));
+				}
+
 				const img = new Image();
 				img.onload = async () => {
 					state.image = img;
@@ -799,6 +825,12 @@ function createAppStore() {
 					state.power = workspace.power || 4;
 					state.maxDistance = workspace.maxDistance || 0;
 
+					// Local and shared workspaces cannot be updated on the server.
+					state.currentWorkspace =
+						workspace.id.startsWith('local_') || workspace.readOnly
+							? null
+							: { id: workspace.id, name: workspace.name, etag };
+
 					drawImageAndBoxes();
 					toast.success('Workspace loaded: ' + workspace.name, { id: toastId });
 				};
@@ -807,7 +839,7 @@ function createAppStore() {
 					toast.error('Failed to load workspace image', { id: toastId });
 				};
 
-				img.src = workspace.imageData;
+				img.src = workspace.imageData ?? '';
 			} catch (err) {
 				toast.error(err instanceof Error ? err.message : 'Failed to load workspace.', { id: toastId });
 			}
@@ -868,6 +900,7 @@ function createAppStore() {
 		async deleteWorkspace(workspaceId: string) {
 			try {
 				const isLocalWorkspace = workspaceId.startsWith('local_');
+				if (state.currentWorkspace?.id === workspaceId) state.currentWorkspace = null;
 
 				if (authStore.state.isAuthenticated && !authStore.isDemoUser() && !isLocalWorkspace) {
 					await workspaceApi.deleteWorkspace(workspaceId);
@@ -905,6 +938,7 @@ function createAppStore() {
 							await Promise.all(
 								localWorkspaces.map(async (workspace) => {
 									try {
+										if (!workspace.imageData) return;
 										await workspaceApi.saveWorkspace(workspace.name, workspace.imageData, {
 											colors: workspace.colors || [],
 											selectors: workspace.selectors || [],
@@ -1,5 +1,6 @@
 export type Color = {
 	hex: string;
+	name?: string;
 };
 
 export type PaletteData = {
@@ -8,6 +9,13 @@ export type PaletteData = {
 	palette: Color[];
 	createdAt: string;
 	isSystem?: boolean;
+	share?: PaletteShare | null;
+};
+
+export type PaletteShare = {
+	shareToken: string;
+	jsonUrl: string;
+	svgUrl: string;
 };
 
 export type SavePaletteRequest = {
@@ -17,6 +25,7 @@ export type SavePaletteRequest = {
 
 export type GetPalettesResponse = {
 	palettes: PaletteData[];
+	nextCursor?: string;
 };
 
 export type SavePaletteResult = {
@@ -39,7 +48,9 @@ export type Selector = {
 export type WorkspaceData = {
 	id: string;
 	name: string;
-	imageData: string;
+	// Absent in list responses; fetched with getWorkspace when loading.
+	imageData?: string;
+	thumbnailUrl?: string;
 	colors?: Color[];
 	selectors?: Selector[];
 	activeSelectorId?: string;
@@ -48,7 +59,13 @@ export type WorkspaceData = {
 	power?: number;
 	maxDistance?: number;
 	shareToken?: string | null;
+	share?: ShareWorkspaceResult | null;
+	forkedFromId?: string;
+	forkCount?: number;
+	// Set on workspaces opened through someone else's share link.
+	readOnly?: boolean;
 	createdAt: string;
+	updatedAt?: string;
 };
 
 export type SaveWorkspaceRequest = {
@@ -65,14 +82,32 @@ export type SaveWorkspaceRequest = {
 
 export type GetWorkspacesResponse = {
 	workspaces: WorkspaceData[];
+	nextCursor?: string;
 };
 
 export type SaveWorkspaceResult = {
 	message: string;
+	id: string;
 	name: string;
+	// From the ETag header; updateWorkspace sends it back as If-Match.
+	etag: string | null;
+};
+
+// A workspace with the ETag of the version it was read at.
+export type VersionedWorkspace = {
+	workspace: WorkspaceData;
+	etag: string | null;
+};
+
+export type ShareWorkspaceOptions = {
+	expiresAt?: string;
+	password?: string;
 };
 
 export type ShareWorkspaceResult = {
 	shareToken: string;
 	shareUrl: string;
+	expiresAt?: string;
+	passwordProtected: boolean;
+	views: number;
 };